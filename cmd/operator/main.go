@@ -20,12 +20,16 @@ import (
 	"context"
 	"flag"
 	"os"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	extsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -45,28 +49,51 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	v1alpha2converters "github.com/travelaudience/aerospike-operator/pkg/crd/converters/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+	"github.com/travelaudience/aerospike-operator/pkg/metrics"
 	"github.com/travelaudience/aerospike-operator/pkg/signals"
 	flagutils "github.com/travelaudience/aerospike-operator/pkg/utils/flags"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 const (
-	admissionEnabledFlag = "admission-enabled"
-	debugEnabledFlag     = "debug"
-	kubeconfigFlag       = "kubeconfig"
+	admissionEnabledFlag      = "admission-enabled"
+	debugEnabledFlag          = "debug"
+	imagePullPolicyFlag       = "image-pull-policy"
+	imagePullSecretsFlag      = "image-pull-secrets"
+	kubeconfigFlag            = "kubeconfig"
+	metricsAddressFlag        = "metrics-address"
+	serverImageRepositoryFlag = "aerospike-server-image-repository"
+	toolsImageRepositoryFlag  = "tools-image-repository"
+	versionCatalogFileFlag    = "version-catalog-file"
+	versionCatalogCMFlag      = "version-catalog-configmap"
+
+	// the interval at which the version catalog is reloaded
+	versionCatalogReloadInterval = 1 * time.Minute
 )
 
 var (
-	fs         *flag.FlagSet
-	kubeconfig string
-	wh         *admission.ValidatingAdmissionWebhook
+	fs                 *flag.FlagSet
+	imagePullSecrets   string
+	kubeconfig         string
+	metricsAddress     string
+	versionCatalogFile string
+	versionCatalogCM   string
+	wh                 *admission.ValidatingAdmissionWebhook
 )
 
 func init() {
 	fs = flag.NewFlagSet("", flag.ExitOnError)
 	fs.BoolVar(&debug.DebugEnabled, debugEnabledFlag, false, "[DEPRECATED] Whether to enable debug mode.")
 	fs.StringVar(&kubeconfig, kubeconfigFlag, "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	fs.StringVar(&metricsAddress, metricsAddressFlag, ":8080", "The address at which to expose prometheus metrics. If empty, metrics are not exposed.")
 	fs.BoolVar(&admission.Enabled, admissionEnabledFlag, true, "[DEPRECATED] Whether to enable the validating admission webhook.")
+	fs.StringVar(&images.ServerRepository, serverImageRepositoryFlag, images.DefaultServerRepository, "The repository of the aerospike server image to use when not specified in the aerospikecluster resource.")
+	fs.StringVar(&images.ToolsRepository, toolsImageRepositoryFlag, images.DefaultToolsRepository, "The repository of the aerospike-operator-tools image to use when not specified in the aerospikecluster resource.")
+	fs.StringVar(&images.PullPolicy, imagePullPolicyFlag, "", "The pull policy to use for all containers when not specified in the aerospikecluster resource.")
+	fs.StringVar(&imagePullSecrets, imagePullSecretsFlag, "", "Comma-separated list of names of the secrets to use for pulling images when not specified in the aerospikecluster resource.")
+	fs.StringVar(&versionCatalogFile, versionCatalogFileFlag, "", "Path to a file holding the catalog of supported aerospike versions and upgrades. If not specified, the compiled-in catalog is used.")
+	fs.StringVar(&versionCatalogCM, versionCatalogCMFlag, "", "Name of the configmap (in the namespace of aerospike-operator) holding the catalog of supported aerospike versions and upgrades. If not specified, the compiled-in catalog is used.")
 }
 
 func main() {
@@ -75,6 +102,20 @@ func main() {
 	// warn about deprecated flags
 	flagutils.DeprecateFlags(fs, admissionEnabledFlag, debugEnabledFlag)
 
+	// validate the image-related flags
+	switch v1.PullPolicy(images.PullPolicy) {
+	case "", v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+	default:
+		log.Fatalf("invalid value for --%s: %q", imagePullPolicyFlag, images.PullPolicy)
+	}
+	if imagePullSecrets != "" {
+		images.PullSecrets = strings.Split(imagePullSecrets, ",")
+	}
+	// validate the version catalog flags
+	if versionCatalogFile != "" && versionCatalogCM != "" {
+		log.Fatalf("only one of --%s and --%s may be specified", versionCatalogFileFlag, versionCatalogCMFlag)
+	}
+
 	// workaround for https://github.com/kubernetes/kubernetes/issues/17162
 	flag.CommandLine.Parse([]string{})
 
@@ -119,6 +160,19 @@ func main() {
 		log.Fatalf("failed to create aerospike clientset: %v", err)
 	}
 
+	// load the version catalog (if one was specified) and keep reloading it
+	// so that changes are picked up without restarting
+	if versionCatalogFile != "" || versionCatalogCM != "" {
+		if err := loadVersionCatalog(kubeClient, namespace); err != nil {
+			log.Fatalf("failed to load version catalog: %v", err)
+		}
+		go wait.Until(func() {
+			if err := loadVersionCatalog(kubeClient, namespace); err != nil {
+				log.Errorf("failed to reload version catalog, keeping the current one: %v", err)
+			}
+		}, versionCatalogReloadInterval, shCh)
+	}
+
 	// register (if enabled) and run the validating admission webhook and health
 	// endpoint
 	wh = admission.NewValidatingAdmissionWebhook(namespace, kubeClient, aerospikeClient)
@@ -127,6 +181,11 @@ func main() {
 	}
 	go wh.Run(shCh)
 
+	// expose prometheus metrics (if enabled)
+	if metricsAddress != "" {
+		go metrics.Serve(metricsAddress, shCh)
+	}
+
 	log.Info("attempting to become leader")
 
 	// setup a resourcelock for leader election
@@ -172,6 +231,34 @@ func main() {
 	})
 }
 
+// loadVersionCatalog loads the version catalog from the file or configmap
+// specified via flags and starts using it.
+func loadVersionCatalog(kubeClient kubernetes.Interface, namespace string) error {
+	var (
+		c   *versioning.Catalog
+		err error
+	)
+	if versionCatalogFile != "" {
+		c, err = versioning.LoadCatalogFromFile(versionCatalogFile)
+	} else {
+		c, err = versioning.LoadCatalogFromConfigMap(kubeClient, namespace, versionCatalogCM)
+	}
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(c, versioning.GetCatalog()) {
+		return nil
+	}
+	if err := versioning.SetCatalog(c); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"versions": len(c.Versions),
+		"upgrades": len(c.Upgrades),
+	}).Info("version catalog loaded")
+	return nil
+}
+
 func createRecorder(kubeClient kubernetes.Interface, name, namespace string) record.EventRecorder {
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(log.Infof)
@@ -188,6 +275,11 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 		log.Fatalf("failed to create custom resource definitions: %v", err)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		log.Fatalf("failed to create dynamic client: %v", err)
+	}
+
 	aerospikescheme.AddToScheme(scheme.Scheme)
 
 	kubeInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, time.Second*30)
@@ -203,11 +295,18 @@ func run(stopCh chan struct{}, cfg *restclient.Config, kubeClient *kubernetes.Cl
 		log.Fatalf("failed to upgrade existing resources to v1alpha2: %v", err)
 	}
 
-	clusterController := controller.NewAerospikeClusterController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
+	clusterController := controller.NewAerospikeClusterController(kubeClient, aerospikeClient, dynamicClient, kubeInformerFactory, aerospikeInformerFactory)
 	backupController := controller.NewAerospikeNamespaceBackupController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 	restoreController := controller.NewAerospikeNamespaceRestoreController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 	gcController := controller.NewGarbageCollectorController(kubeClient, aerospikeClient, kubeInformerFactory, aerospikeInformerFactory)
 
+	// register the collector of the metrics about aerospikecluster resources
+	if err := metrics.RegisterClusterCollector(
+		aerospikeInformerFactory.Aerospike().V1alpha2().AerospikeClusters().Lister(),
+		kubeInformerFactory.Core().V1().PersistentVolumeClaims().Lister()); err != nil {
+		log.Fatalf("failed to register metrics collector: %v", err)
+	}
+
 	// start the shared informer factories
 	go kubeInformerFactory.Start(stopCh)
 	go aerospikeInformerFactory.Start(stopCh)
@@ -51,6 +51,7 @@ require (
 	github.com/onsi/ginkgo v1.5.0
 	github.com/onsi/gomega v1.4.0
 	github.com/pborman/uuid v0.0.0-20170612153648-e790cca94e6c // indirect
+	github.com/prometheus/client_golang v0.9.2
 	github.com/sirupsen/logrus v1.0.5
 	github.com/soheilhy/cmux v0.1.4 // indirect
 	github.com/stretchr/testify v1.3.0
@@ -79,5 +80,5 @@ require (
 	k8s.io/kube-openapi v0.0.0-20190510232812-a01b7d5d6c22
 	k8s.io/kubernetes v1.14.2
 	k8s.io/utils v0.0.0-20190520173318-324c5df7d3f0 // indirect
-	sigs.k8s.io/yaml v1.1.0 // indirect
+	sigs.k8s.io/yaml v1.1.0
 )
@@ -18,14 +18,23 @@ package admission
 
 import (
 	"fmt"
+	"net"
 	"reflect"
+	"strconv"
+	"strings"
 
 	av1beta1 "k8s.io/api/admission/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+	"github.com/travelaudience/aerospike-operator/pkg/reconciler"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
 	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
@@ -55,9 +64,16 @@ const (
 	// backup/restore suffix is appended to the jobs by backups handler. (restore is used for calculation
 	// because it has a greater length)
 	aerospikeNamespaceMaxNameLen = 23
-	// the default replication factor for an aerospike namespace
-	// https://www.aerospike.com/docs/reference/configuration#replication-factor
-	defaultNamespaceReplicationFactor int32 = 2
+	// the annotations marking a storage class as the default one
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+	// the default value of memory-size for an aerospike namespace, as set by
+	// aerospike-operator
+	defaultNamespaceMemorySize = "4G"
+	// maxVolumeCount represents the maximum number of persistent volumes that
+	// can be used across all namespaces of a cluster, which is limited by the
+	// number of available device paths (/dev/xvda to /dev/xvdz)
+	maxVolumeCount = 26
 )
 
 func (s *ValidatingAdmissionWebhook) admitAerospikeCluster(ar av1beta1.AdmissionReview) *av1beta1.AdmissionResponse {
@@ -114,9 +130,12 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		if len(ns.Name) > aerospikeNamespaceMaxNameLen {
 			return fmt.Errorf("the name of a namespace cannot exceed %d characters", aerospikeNamespaceMaxNameLen)
 		}
+		if reconciler.IsReservedNamespaceName(ns.Name) {
+			return fmt.Errorf("%s is a reserved namespace name", ns.Name)
+		}
 		// the current replication factor equals aerospike's default, unless it
 		// has been set by the user
-		currentReplicationFactor := defaultNamespaceReplicationFactor
+		currentReplicationFactor := common.DefaultReplicationFactor
 		if ns.ReplicationFactor != nil {
 			currentReplicationFactor = *ns.ReplicationFactor
 		}
@@ -125,6 +144,11 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 		}
 	}
 
+	// validate the storage configuration of every namespace
+	if err := validateNamespaceStorage(aerospikeCluster); err != nil {
+		return err
+	}
+
 	// if backupSpec is specified, make sure that the secret containing
 	// cloud storage credentials exists and matches the expected format
 	if aerospikeCluster.Spec.BackupSpec != nil {
@@ -142,6 +166,283 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeCluster(aerospikeCluster *
 			return fmt.Errorf("secret %q does not contain expected field %q", secretName, secretKey)
 		}
 	}
+
+	// validate the cross-datacenter replication configuration
+	if err := s.validateXDR(aerospikeCluster); err != nil {
+		return err
+	}
+	// validate the image configuration
+	if err := validateImage(aerospikeCluster); err != nil {
+		return err
+	}
+	// validate the pod customizations
+	if err := validatePodSpec(aerospikeCluster); err != nil {
+		return err
+	}
+	// validate the rollout strategy
+	if err := validateRolloutStrategy(aerospikeCluster); err != nil {
+		return err
+	}
+	// validate the canary upgrade configuration
+	if err := validateCanaryUpgrade(aerospikeCluster); err != nil {
+		return err
+	}
+	// validate the monitoring configuration
+	if err := validateMonitoring(aerospikeCluster); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateMonitoring(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// if no servicemonitor is requested, there is nothing to validate
+	if aerospikeCluster.Spec.Monitoring == nil || aerospikeCluster.Spec.Monitoring.ServiceMonitor == nil {
+		return nil
+	}
+	serviceMonitor := aerospikeCluster.Spec.Monitoring.ServiceMonitor
+	if serviceMonitor.Interval == "" || serviceMonitor.ScrapeTimeout == "" {
+		return nil
+	}
+	// prometheus rejects scrape timeouts greater than the scrape interval
+	interval, err := astime.ParseDuration(serviceMonitor.Interval)
+	if err != nil {
+		return err
+	}
+	scrapeTimeout, err := astime.ParseDuration(serviceMonitor.ScrapeTimeout)
+	if err != nil {
+		return err
+	}
+	if scrapeTimeout > interval {
+		return fmt.Errorf("the scrape timeout must not be greater than the scrape interval")
+	}
+	return nil
+}
+
+func validateCanaryUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// if no canary upgrade configuration is specified, there is nothing to validate
+	if aerospikeCluster.Spec.CanaryUpgrade == nil || aerospikeCluster.Spec.CanaryUpgrade.PodCount == nil {
+		return nil
+	}
+	// at least one pod must remain to be upgraded after the soak period
+	if *aerospikeCluster.Spec.CanaryUpgrade.PodCount >= aerospikeCluster.Spec.NodeCount {
+		return fmt.Errorf("the canary pod count must be smaller than the node count")
+	}
+	return nil
+}
+
+func validateRolloutStrategy(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// if no rollout strategy is specified, there is nothing to validate
+	if aerospikeCluster.Spec.RolloutStrategy == nil || aerospikeCluster.Spec.RolloutStrategy.MaxUnavailable == nil {
+		return nil
+	}
+	maxUnavailable := *aerospikeCluster.Spec.RolloutStrategy.MaxUnavailable
+	// at least one pod must remain available during a rollout
+	if maxUnavailable >= aerospikeCluster.Spec.NodeCount && maxUnavailable > 1 {
+		return fmt.Errorf("maxUnavailable must be smaller than the node count")
+	}
+	// restarting every replica of a partition at the same time makes it
+	// unavailable (and loses its data if it is stored in memory), so
+	// maxUnavailable must be smaller than the replication factor of every
+	// namespace, as enforced by the cluster's pod disruption budget
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		replicationFactor := common.DefaultReplicationFactor
+		if ns.ReplicationFactor != nil {
+			replicationFactor = *ns.ReplicationFactor
+		}
+		if maxUnavailable > 1 && maxUnavailable >= replicationFactor {
+			return fmt.Errorf("maxUnavailable must be smaller than the replication factor of namespace %s", ns.Name)
+		}
+	}
+	return nil
+}
+
+func validateNamespaceStorage(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	volumeCount := 0
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		// namespaces stored in memory use no persistent volumes
+		if ns.Storage.Type == common.StorageTypeMemory {
+			if ns.Storage.Size != "" || ns.Storage.VolumeCount != nil || ns.Storage.StorageClassName != nil || ns.Storage.Shadow != nil || ns.Storage.LocalVolumeReleaseTimeout != nil {
+				return fmt.Errorf("size, volumeCount, storageClassName, shadow and localVolumeReleaseTimeout cannot be specified for namespace %s as its storage type is %s", ns.Name, common.StorageTypeMemory)
+			}
+			continue
+		}
+		if ns.Storage.Size == "" {
+			return fmt.Errorf("the storage size for namespace %s must be specified", ns.Name)
+		}
+		if ns.Storage.Shadow != nil && ns.Storage.Type != common.StorageTypeDevice {
+			return fmt.Errorf("shadow devices can only be specified for namespace %s if its storage type is %s", ns.Name, common.StorageTypeDevice)
+		}
+		count := int32(1)
+		if ns.Storage.VolumeCount != nil {
+			count = *ns.Storage.VolumeCount
+		}
+		if count < 1 {
+			return fmt.Errorf("the volume count for namespace %s must be positive", ns.Name)
+		}
+		volumeCount += int(count)
+		// every device has its own shadow device
+		if ns.Storage.Shadow != nil {
+			volumeCount += int(count)
+		}
+		// the combined size of the volumes must allow for keeping a persistent
+		// copy of all data held in memory
+		if ns.Storage.DataInMemory != nil && *ns.Storage.DataInMemory {
+			size, err := resource.ParseQuantity(ns.Storage.Size)
+			if err != nil {
+				return err
+			}
+			combinedSize := resource.NewQuantity(size.Value()*int64(count), size.Format)
+			memorySize := defaultNamespaceMemorySize
+			if ns.MemorySize != nil && *ns.MemorySize != "" {
+				memorySize = *ns.MemorySize
+			}
+			memory, err := resource.ParseQuantity(memorySize)
+			if err != nil {
+				return err
+			}
+			if combinedSize.Cmp(memory) < 0 {
+				return fmt.Errorf("the combined storage size for namespace %s (%s) must not be smaller than its memory size (%s) when data-in-memory is enabled", ns.Name, combinedSize.String(), memorySize)
+			}
+		}
+	}
+	if volumeCount > maxVolumeCount {
+		return fmt.Errorf("the cluster cannot use more than %d volumes (including shadow devices) across all namespaces", maxVolumeCount)
+	}
+	return nil
+}
+
+func validatePodSpec(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// if no pod customizations are specified, there is nothing to validate
+	if aerospikeCluster.Spec.PodSpec == nil {
+		return nil
+	}
+	// the labels used to select the pods of the cluster cannot be overridden
+	for _, key := range []string{selectors.LabelAppKey, selectors.LabelClusterKey} {
+		if _, ok := aerospikeCluster.Spec.PodSpec.Labels[key]; ok {
+			return fmt.Errorf("label %q is reserved and cannot be specified", key)
+		}
+	}
+	// sidecar names must be unique and must not clash with the containers
+	// managed by aerospike-operator
+	containers := make(map[string]bool, len(aerospikeCluster.Spec.PodSpec.Sidecars))
+	for _, sidecar := range aerospikeCluster.Spec.PodSpec.Sidecars {
+		if reconciler.IsReservedContainerName(sidecar.Name) {
+			return fmt.Errorf("container name %q is reserved", sidecar.Name)
+		}
+		if containers[sidecar.Name] {
+			return fmt.Errorf("sidecar names must be unique")
+		}
+		containers[sidecar.Name] = true
+	}
+	// volume names must be unique and must not clash with the volumes managed
+	// by aerospike-operator
+	volumes := make(map[string]bool, len(aerospikeCluster.Spec.PodSpec.Volumes))
+	for _, volume := range aerospikeCluster.Spec.PodSpec.Volumes {
+		if reconciler.IsReservedVolumeName(volume.Name) {
+			return fmt.Errorf("volume name %q is reserved", volume.Name)
+		}
+		if volumes[volume.Name] {
+			return fmt.Errorf("volume names must be unique")
+		}
+		volumes[volume.Name] = true
+	}
+	return nil
+}
+
+func validateImage(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// if no image configuration is specified, there is nothing to validate
+	if aerospikeCluster.Spec.Image == nil {
+		return nil
+	}
+	// images must be referenced by tag and not by digest, as the tag is used
+	// to select the version of aerospike server and aerospike-operator-tools
+	if strings.Contains(aerospikeCluster.Spec.Image.Server, "@") || strings.Contains(aerospikeCluster.Spec.Image.Tools, "@") {
+		return fmt.Errorf("images cannot be referenced by digest")
+	}
+	// the tag of a custom aerospike server image, if specified, must match
+	// the requested version of aerospike
+	if _, tag := images.SplitImage(aerospikeCluster.Spec.Image.Server); tag != "" && tag != aerospikeCluster.Spec.Version {
+		return fmt.Errorf("the tag of image %q does not match version %s", aerospikeCluster.Spec.Image.Server, aerospikeCluster.Spec.Version)
+	}
+	// the tag of the aerospike-operator-tools image is always set to the
+	// current version of aerospike-operator
+	if _, tag := images.SplitImage(aerospikeCluster.Spec.Image.Tools); tag != "" {
+		return fmt.Errorf("the aerospike-operator-tools image %q must not include a tag", aerospikeCluster.Spec.Image.Tools)
+	}
+	return nil
+}
+
+func (s *ValidatingAdmissionWebhook) validateXDR(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// if xdr is not enabled, there is nothing to validate
+	if aerospikeCluster.Spec.XDR == nil {
+		return nil
+	}
+	// xdr is only available in aerospike enterprise, while the default image
+	// runs aerospike community
+	if aerospikeCluster.Spec.Image == nil || aerospikeCluster.Spec.Image.Server == "" {
+		return fmt.Errorf("xdr requires an aerospike enterprise image to be specified in .spec.image.server")
+	}
+	if repository, _ := images.SplitImage(aerospikeCluster.Spec.Image.Server); repository == images.DefaultServerRepository {
+		return fmt.Errorf("xdr is not available in the %s image, which runs aerospike community", images.DefaultServerRepository)
+	}
+	// grab a name => spec map for the namespaces in the cluster
+	nss := namespaceMap(aerospikeCluster)
+	// keep track of destination names so we can make sure they are unique
+	names := make(map[string]bool, len(aerospikeCluster.Spec.XDR.Destinations))
+
+	for _, destination := range aerospikeCluster.Spec.XDR.Destinations {
+		if names[destination.Name] {
+			return fmt.Errorf("xdr destination names must be unique")
+		}
+		names[destination.Name] = true
+
+		// exactly one of cluster and seedAddresses must be specified
+		if (destination.Cluster == nil) == (len(destination.SeedAddresses) == 0) {
+			return fmt.Errorf("exactly one of cluster and seedAddresses must be specified for xdr destination %s", destination.Name)
+		}
+		// make sure every seed address is in the host:port format
+		for _, address := range destination.SeedAddresses {
+			_, port, err := net.SplitHostPort(address)
+			if err != nil {
+				return fmt.Errorf("invalid seed address %q for xdr destination %s: %v", address, destination.Name, err)
+			}
+			if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+				return fmt.Errorf("invalid port in seed address %q for xdr destination %s", address, destination.Name)
+			}
+		}
+		// make sure that only existing namespaces are shipped
+		for _, ns := range destination.Namespaces {
+			if _, ok := nss[ns]; !ok {
+				return fmt.Errorf("xdr destination %s references unknown namespace %s", destination.Name, ns)
+			}
+		}
+
+		if destination.Cluster == nil {
+			continue
+		}
+		// make sure that the referenced aerospikecluster exists, is not the
+		// current one, and contains every namespace to be shipped
+		namespace := destination.Cluster.Namespace
+		if namespace == "" {
+			namespace = aerospikeCluster.Namespace
+		}
+		if namespace == aerospikeCluster.Namespace && destination.Cluster.Name == aerospikeCluster.Name {
+			return fmt.Errorf("xdr destination %s cannot reference the current cluster", destination.Name)
+		}
+		remote, err := s.aerospikeClient.AerospikeV1alpha2().AerospikeClusters(namespace).Get(destination.Cluster.Name, v1.GetOptions{})
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return fmt.Errorf("aerospikecluster %q not found in namespace %q", destination.Cluster.Name, namespace)
+			}
+			return err
+		}
+		remotenss := namespaceMap(remote)
+		for _, ns := range destination.Namespaces {
+			if _, ok := remotenss[ns]; !ok {
+				return fmt.Errorf("aerospikecluster %q in namespace %q does not contain namespace %s", destination.Cluster.Name, namespace, ns)
+			}
+		}
+	}
 	return nil
 }
 
@@ -153,6 +454,15 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 		tmp := new.DeepCopy()
 		// set tmp.Spec.Version to old.Spec.Version
 		tmp.Spec.Version = old.Spec.Version
+		// allow the tag of a custom aerospike server image to be changed
+		// along with .spec.version
+		if old.Spec.Image != nil && tmp.Spec.Image != nil {
+			oldRepository, _ := images.SplitImage(old.Spec.Image.Server)
+			newRepository, _ := images.SplitImage(tmp.Spec.Image.Server)
+			if oldRepository == newRepository {
+				tmp.Spec.Image.Server = old.Spec.Image.Server
+			}
+		}
 		// check if old.Spec and tmp.Spec differ
 		// if they do, more than just .spec.Version has been been changed
 		// between old and new, and new must be rejected
@@ -170,7 +480,7 @@ func (s *ValidatingAdmissionWebhook) validateAerospikeClusterUpdate(old, new *ae
 		return err
 	}
 	// validate the namespace configuration
-	if err := validateNamespaces(old, new); err != nil {
+	if err := s.validateNamespaces(old, new); err != nil {
 		return err
 	}
 
@@ -182,6 +492,18 @@ func validateVersion(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	if old.Spec.Version == new.Spec.Version {
 		return nil
 	}
+	// rolling back a failed or paused upgrade to the version the cluster was
+	// previously running is allowed, and so is requesting the version a
+	// rolled back cluster is running
+	switch old.Annotations[reconciler.UpgradeStatusAnnotationKey] {
+	case reconciler.UpgradeStatusFailedAnnotationValue, reconciler.UpgradeStatusPausedAnnotationValue:
+		if new.Spec.Version == old.Status.Version {
+			return nil
+		}
+	}
+	if _, ok := old.Annotations[reconciler.UpgradeRolledBackVersionAnnotationKey]; ok && new.Spec.Version == old.Status.Version {
+		return nil
+	}
 	// validate the requested version transition
 	sourceVersion, err := versioning.NewVersionFromString(old.Spec.Version)
 	if err != nil {
@@ -191,15 +513,17 @@ func validateVersion(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	if err != nil {
 		return err
 	}
-	upgrade := versioning.VersionUpgrade{sourceVersion, targetVersion}
-	// return an error if the transition is not supported
-	if !upgrade.IsValid() {
+	// return an error if the target version cannot be reached from the
+	// source version, either directly or through intermediate versions
+	if plan, err := versioning.PlanUpgrade(sourceVersion, targetVersion); err != nil {
+		return err
+	} else if len(plan) == 0 {
 		return fmt.Errorf("cannot upgrade from version %v to %v", sourceVersion, targetVersion)
 	}
 	return nil
 }
 
-func validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
+func (s *ValidatingAdmissionWebhook) validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
 	// grab a name => spec map for the namespaces in the old object
 	oldnss := namespaceMap(old)
 	// grab a name => spec map for the namespaces in the new object
@@ -225,13 +549,102 @@ func validateNamespaces(old, new *aerospikev1alpha2.AerospikeCluster) error {
 			return fmt.Errorf("cannot change the replication factor for namespace %s", name)
 		}
 		// make sure that the storage spec hasn't been changed
-		if !reflect.DeepEqual(oldnss[name].Storage, newnss[name].Storage) {
-			return fmt.Errorf("cannot change the storage spec for namespace %s", name)
+		if err := s.validateStorage(newnss[name], oldnss[name].Storage, newnss[name].Storage); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+func (s *ValidatingAdmissionWebhook) validateStorage(namespace aerospikev1alpha2.AerospikeNamespaceSpec, old, new aerospikev1alpha2.StorageSpec) error {
+	// namespaces cannot be moved in or out of memory, as either the data or
+	// the persistent volumes holding it would be lost
+	if (old.Type == common.StorageTypeMemory) != (new.Type == common.StorageTypeMemory) {
+		return fmt.Errorf("cannot change the storage type of namespace %s from %s to %s", namespace.Name, old.Type, new.Type)
+	}
+	if new.Type == common.StorageTypeMemory {
+		return nil
+	}
+	// the size of the storage may only be increased
+	oldSize, err := resource.ParseQuantity(old.Size)
+	if err != nil {
+		return err
+	}
+	newSize, err := resource.ParseQuantity(new.Size)
+	if err != nil {
+		return err
+	}
+	if newSize.Cmp(oldSize) < 0 {
+		return fmt.Errorf("cannot decrease the storage size for namespace %s", namespace.Name)
+	}
+	// the storage type and storage class may be changed, and the size of
+	// storage which cannot be expanded in place may be increased, in which case
+	// data is migrated to new persistent volumes one node at a time. this
+	// requires every record to have at least one other copy in the cluster.
+	replicationFactor := common.DefaultReplicationFactor
+	if namespace.ReplicationFactor != nil {
+		replicationFactor = *namespace.ReplicationFactor
+	}
+	if replicationFactor < 2 {
+		if old.Type != new.Type || !reflect.DeepEqual(old.StorageClassName, new.StorageClassName) {
+			return fmt.Errorf("cannot change the storage type or storage class for namespace %s as its replication factor is less than 2", namespace.Name)
+		}
+		if newSize.Cmp(oldSize) > 0 {
+			expandable, err := s.canExpandStorage(new)
+			if err != nil {
+				return err
+			}
+			if !expandable {
+				return fmt.Errorf("cannot increase the storage size for namespace %s as its storage cannot be expanded in place and its replication factor is less than 2", namespace.Name)
+			}
+		}
+	}
+	// all other fields of the storage spec must remain unchanged
+	old.Size, new.Size = "", ""
+	old.Type, new.Type = "", ""
+	old.StorageClassName, new.StorageClassName = nil, nil
+	old.LocalVolumeReleaseTimeout, new.LocalVolumeReleaseTimeout = nil, nil
+	if !reflect.DeepEqual(old, new) {
+		return fmt.Errorf("cannot change the storage spec for namespace %s", namespace.Name)
+	}
+	return nil
+}
+
+// canExpandStorage returns whether persistent volume claims created according
+// to the specified storage spec can be expanded in place. Only file-based
+// storage whose storage class allows for volume expansion can be expanded in
+// place (see AerospikeClusterReconciler.canExpandPersistentVolumeClaim).
+func (s *ValidatingAdmissionWebhook) canExpandStorage(storage aerospikev1alpha2.StorageSpec) (bool, error) {
+	if storage.Type != common.StorageTypeFile {
+		return false, nil
+	}
+	// persistent volume claims which don't specify a storage class use the
+	// default one
+	if storage.StorageClassName == nil {
+		scs, err := s.kubeClient.StorageV1().StorageClasses().List(v1.ListOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, sc := range scs.Items {
+			if sc.Annotations[defaultStorageClassAnnotation] == "true" || sc.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+				return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+			}
+		}
+		return false, nil
+	}
+	if *storage.StorageClassName == "" {
+		return false, nil
+	}
+	sc, err := s.kubeClient.StorageV1().StorageClasses().Get(*storage.StorageClassName, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
 func namespaceMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) map[string]aerospikev1alpha2.AerospikeNamespaceSpec {
 	res := make(map[string]aerospikev1alpha2.AerospikeNamespaceSpec, len(aerospikeCluster.Spec.Namespaces))
 	for _, ns := range aerospikeCluster.Spec.Namespaces {
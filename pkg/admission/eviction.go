@@ -0,0 +1,95 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package admission
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	av1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/reconciler"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+func (s *ValidatingAdmissionWebhook) admitEviction(ar av1beta1.AdmissionReview) *av1beta1.AdmissionResponse {
+	// get the pod which is being evicted
+	pod, err := s.kubeClient.CoreV1().Pods(ar.Request.Namespace).Get(ar.Request.Name, v1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return &av1beta1.AdmissionResponse{Allowed: true}
+		}
+		return admissionResponseFromError(err)
+	}
+	// only pods belonging to an aerospike cluster are subject to validation
+	clusterName, ok := pod.Labels[selectors.LabelClusterKey]
+	if !ok || pod.Labels[selectors.LabelAppKey] != selectors.LabelAppVal {
+		return &av1beta1.AdmissionResponse{Allowed: true}
+	}
+	// a pod which is not ready is not serving any data and may be evicted
+	if !reconciler.IsPodRunningAndReady(pod) {
+		return &av1beta1.AdmissionResponse{Allowed: true}
+	}
+	// validate that the cluster can tolerate losing the pod
+	if err := s.validateEviction(clusterName, pod.Namespace, pod.Name); err != nil {
+		log.WithFields(log.Fields{
+			logfields.Pod: meta.Key(pod),
+		}).Debugf("denying eviction: %v", err)
+		return &av1beta1.AdmissionResponse{
+			Result: &v1.Status{
+				Status:  v1.StatusFailure,
+				Code:    http.StatusTooManyRequests,
+				Reason:  v1.StatusReasonTooManyRequests,
+				Message: err.Error(),
+			},
+		}
+	}
+	// admit the eviction
+	return &av1beta1.AdmissionResponse{Allowed: true}
+}
+
+// validateEviction checks whether every pod in the specified aerospike cluster
+// other than the one being evicted is running and ready, and that no migrations
+// are in progress in the cluster.
+func (s *ValidatingAdmissionWebhook) validateEviction(clusterName, namespace, podName string) error {
+	pods, err := s.kubeClient.CoreV1().Pods(namespace).List(v1.ListOptions{
+		LabelSelector: selectors.ResourcesByClusterName(clusterName).String(),
+	})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		if pod.Name != podName && !reconciler.IsPodRunningAndReady(&pod) {
+			return fmt.Errorf("pod %s is not ready", meta.Key(&pod))
+		}
+	}
+	for _, pod := range pods.Items {
+		migrations, err := reconciler.PodHasMigrationsInProgress(&pod)
+		if err != nil {
+			return fmt.Errorf("failed to check for migrations on pod %s: %v", meta.Key(&pod), err)
+		}
+		if migrations {
+			return fmt.Errorf("pod %s has migrations in progress", meta.Key(&pod))
+		}
+	}
+	return nil
+}
@@ -65,9 +65,14 @@ var (
 	aerospikeClusterWebhookPath          = "/admission/reviews/aerospikeclusters"
 	aerospikeNamespaceBackupWebhookPath  = "/admission/reviews/aerospikenamespacebackups"
 	aerospikeNamespaceRestoreWebhookPath = "/admission/reviews/aerospikenamespacerestores"
+	evictionWebhookPath                  = "/admission/reviews/evictions"
 	healthzPath                          = "/healthz"
 
 	failurePolicy = admissionregistrationv1beta1.Fail
+	// evictionFailurePolicy is the failure policy used for pod evictions.
+	// evictions are admitted if the webhook is unavailable so that node
+	// drains are not blocked cluster-wide.
+	evictionFailurePolicy = admissionregistrationv1beta1.Ignore
 )
 
 const (
@@ -137,6 +142,7 @@ func (s *ValidatingAdmissionWebhook) Run(stopCh chan struct{}) {
 	mux.HandleFunc(aerospikeClusterWebhookPath, s.handleAerospikeCluster)
 	mux.HandleFunc(aerospikeNamespaceBackupWebhookPath, s.handleAerospikeNamespaceBackup)
 	mux.HandleFunc(aerospikeNamespaceRestoreWebhookPath, s.handleAerospikeNamespaceRestore)
+	mux.HandleFunc(evictionWebhookPath, s.handleEviction)
 	mux.HandleFunc(healthzPath, handleHealthz)
 	srv := http.Server{
 		Addr:    fmt.Sprintf(":%d", 8443),
@@ -174,6 +180,10 @@ func (s *ValidatingAdmissionWebhook) handleAerospikeNamespaceRestore(res http.Re
 	handle(res, req, s.admitAerospikeNamespaceRestore)
 }
 
+func (s *ValidatingAdmissionWebhook) handleEviction(res http.ResponseWriter, req *http.Request) {
+	handle(res, req, s.admitEviction)
+}
+
 // ensureTLSSecret generates a certificate and private key to be used for registering and serving the webhook, and
 // creates a kubernetes secret containing them so they can be used by all running instances of aerospike-operator.
 // in case such secret already exists, it is read and returned.
@@ -339,6 +349,34 @@ func (s *ValidatingAdmissionWebhook) ensureWebhookConfig(caBundle []byte) error
 				},
 				FailurePolicy: &failurePolicy,
 			},
+			{
+				Name: fmt.Sprintf("evictions.%s", aerospike.GroupName),
+				Rules: []admissionregistrationv1beta1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1beta1.OperationType{
+							admissionregistrationv1beta1.Create,
+						},
+						Rule: admissionregistrationv1beta1.Rule{
+							APIGroups: []string{
+								v1.GroupName,
+							},
+							APIVersions: []string{
+								v1.SchemeGroupVersion.Version,
+							},
+							Resources: []string{"pods/eviction"},
+						},
+					},
+				},
+				ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{
+					Service: &admissionregistrationv1beta1.ServiceReference{
+						Name:      serviceName,
+						Namespace: s.namespace,
+						Path:      &evictionWebhookPath,
+					},
+					CABundle: caBundle,
+				},
+				FailurePolicy: &evictionFailurePolicy,
+			},
 		},
 	}
 
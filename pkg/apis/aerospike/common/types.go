@@ -27,9 +27,33 @@ const (
 	// StorageTypeDevice defines the device storage type for a given Aerospike namespace.
 	StorageTypeDevice = "device"
 
+	// StorageTypeMemory defines the memory storage type for a given Aerospike namespace.
+	StorageTypeMemory = "memory"
+
 	// StorageTypeGCS defines the Google Cloud Storage type for a given Aerospike backup.
 	StorageTypeGCS = "gcs"
 
+	// DefaultReplicationFactor is the replication factor of an Aerospike namespace for which none
+	// is specified.
+	// https://www.aerospike.com/docs/reference/configuration#replication-factor
+	DefaultReplicationFactor int32 = 2
+
+	// RolloutOrderAscending defines that the pods of an Aerospike cluster are restarted from the
+	// lowest to the highest index.
+	RolloutOrderAscending = "Ascending"
+
+	// RolloutOrderDescending defines that the pods of an Aerospike cluster are restarted from the
+	// highest to the lowest index.
+	RolloutOrderDescending = "Descending"
+
+	// UpgradeRecoveryActionRollback defines that an Aerospike cluster is rolled back to the
+	// version it was running when a version upgrade fails.
+	UpgradeRecoveryActionRollback = "Rollback"
+
+	// UpgradeRecoveryActionRetry defines that a failed version upgrade of an Aerospike cluster
+	// is retried.
+	UpgradeRecoveryActionRetry = "Retry"
+
 	// ConditionBackupFailed defines a status condition that indicates that a backup job has failed
 	ConditionBackupFailed apiextensions.CustomResourceDefinitionConditionType = "BackupFailed"
 
@@ -72,6 +96,145 @@ const (
 	// backup for an Aerospike cluster has failed
 	ConditionAutoBackupFailed apiextensions.CustomResourceDefinitionConditionType = "AutoBackupFailed"
 
+	// ConditionScaleDownRejected defines a status condition that indicates that a scale-down
+	// operation on an Aerospike cluster has been rejected because the remaining nodes would
+	// not have enough capacity to hold the data
+	ConditionScaleDownRejected apiextensions.CustomResourceDefinitionConditionType = "ScaleDownRejected"
+
+	// ConditionStorageUpdateInProgress defines a status condition that indicates that the
+	// persistent volume claims used by an Aerospike cluster are being updated to match the
+	// storage spec of its namespaces
+	ConditionStorageUpdateInProgress apiextensions.CustomResourceDefinitionConditionType = "StorageUpdateInProgress"
+
+	// ConditionReconciliationPaused defines a status condition that indicates that reconciliation
+	// of an Aerospike cluster has been paused
+	ConditionReconciliationPaused apiextensions.CustomResourceDefinitionConditionType = "ReconciliationPaused"
+
+	// ConditionUpgradeRollbackInProgress defines a status condition that indicates that an
+	// Aerospike cluster is being rolled back after a failed upgrade
+	ConditionUpgradeRollbackInProgress apiextensions.CustomResourceDefinitionConditionType = "UpgradeRollbackInProgress"
+
+	// ConditionUpgradeRetried defines a status condition that indicates that a failed upgrade to
+	// an Aerospike cluster has been retried
+	ConditionUpgradeRetried apiextensions.CustomResourceDefinitionConditionType = "UpgradeRetried"
+
+	// ConditionUpgradePaused defines a status condition that indicates that an upgrade to an
+	// Aerospike cluster has been halted because the canary pods have failed a health check
+	ConditionUpgradePaused apiextensions.CustomResourceDefinitionConditionType = "UpgradePaused"
+
+	// ConditionReady defines a status condition that indicates whether every node of an
+	// Aerospike cluster is ready and the Aerospike cluster has integrity
+	ConditionReady apiextensions.CustomResourceDefinitionConditionType = "Ready"
+
+	// ConditionProgressing defines a status condition that indicates whether an Aerospike
+	// cluster is being changed in order to match its spec
+	ConditionProgressing apiextensions.CustomResourceDefinitionConditionType = "Progressing"
+
+	// ConditionDegraded defines a status condition that indicates whether an Aerospike cluster
+	// has failed to reach or to remain in the state described by its spec
+	ConditionDegraded apiextensions.CustomResourceDefinitionConditionType = "Degraded"
+
+	// ConditionScalingInProgress defines a status condition that indicates whether the number
+	// of nodes of an Aerospike cluster is being changed
+	ConditionScalingInProgress apiextensions.CustomResourceDefinitionConditionType = "ScalingInProgress"
+
+	// ConditionConfigApplying defines a status condition that indicates whether the pods of an
+	// Aerospike cluster are being restarted in order to apply a new configuration
+	ConditionConfigApplying apiextensions.CustomResourceDefinitionConditionType = "ConfigApplying"
+
+	// ReasonClusterReady is the reason of the Ready condition when every node of an Aerospike
+	// cluster is ready and the Aerospike cluster has integrity
+	ReasonClusterReady = "ClusterReady"
+
+	// ReasonNodesNotReady is the reason of the Ready and Degraded conditions when some nodes of
+	// an Aerospike cluster are missing or not ready
+	ReasonNodesNotReady = "NodesNotReady"
+
+	// ReasonClusterIntegrityLost is the reason of the Ready and Degraded conditions when the
+	// nodes of an Aerospike cluster do not report cluster integrity
+	ReasonClusterIntegrityLost = "ClusterIntegrityLost"
+
+	// ReasonReconciliationComplete is the reason of the Progressing condition when an Aerospike
+	// cluster matches its spec
+	ReasonReconciliationComplete = "ReconciliationComplete"
+
+	// ReasonReconciliationPaused is the reason of the Progressing condition when reconciliation
+	// of an Aerospike cluster has been paused
+	ReasonReconciliationPaused = "ReconciliationPaused"
+
+	// ReasonUpgradeInProgress is the reason of the Progressing condition when an Aerospike
+	// cluster is being upgraded or rolled back
+	ReasonUpgradeInProgress = "UpgradeInProgress"
+
+	// ReasonUpgradePaused is the reason of the Progressing condition when the upgrade of an
+	// Aerospike cluster has been paused
+	ReasonUpgradePaused = "UpgradePaused"
+
+	// ReasonUpgradeFailed is the reason of the Progressing and Degraded conditions when the
+	// upgrade of an Aerospike cluster has failed
+	ReasonUpgradeFailed = "UpgradeFailed"
+
+	// ReasonUpgradeRollbackInProgress is the reason of the Progressing condition when a failed
+	// upgrade of an Aerospike cluster is being rolled back
+	ReasonUpgradeRollbackInProgress = "UpgradeRollbackInProgress"
+
+	// ReasonUpgradeRolledBack is the reason of the Progressing condition when an Aerospike
+	// cluster is kept at its current version because the upgrade to the requested version has
+	// been rolled back
+	ReasonUpgradeRolledBack = "UpgradeRolledBack"
+
+	// ReasonUpgradeRollbackFailed is the reason of the Progressing and Degraded conditions when
+	// the rollback of a failed upgrade of an Aerospike cluster has failed
+	ReasonUpgradeRollbackFailed = "UpgradeRollbackFailed"
+
+	// ReasonScalingInProgress is the reason of the Progressing condition when the number of
+	// nodes of an Aerospike cluster is being changed
+	ReasonScalingInProgress = "ScalingInProgress"
+
+	// ReasonConfigApplying is the reason of the Progressing condition when the pods of an
+	// Aerospike cluster are being restarted in order to apply a new configuration
+	ReasonConfigApplying = "ConfigApplying"
+
+	// ReasonStorageUpdateInProgress is the reason of the Progressing condition when the
+	// persistent volume claims of an Aerospike cluster are being updated
+	ReasonStorageUpdateInProgress = "StorageUpdateInProgress"
+
+	// ReasonReconcileFailed is the reason of the Degraded condition when the last attempt to
+	// reconcile an Aerospike cluster has failed
+	ReasonReconcileFailed = "ReconcileFailed"
+
+	// ReasonClusterHealthy is the reason of the Degraded condition when an Aerospike cluster
+	// is not degraded
+	ReasonClusterHealthy = "ClusterHealthy"
+
+	// ReasonScalingUp is the reason of the ScalingInProgress condition when nodes are being
+	// added to an Aerospike cluster
+	ReasonScalingUp = "ScalingUp"
+
+	// ReasonScalingDown is the reason of the ScalingInProgress condition when nodes are being
+	// removed from an Aerospike cluster
+	ReasonScalingDown = "ScalingDown"
+
+	// ReasonScaleDownRejected is the reason of the ScalingInProgress condition when a
+	// scale-down operation on an Aerospike cluster has been rejected
+	ReasonScaleDownRejected = "ScaleDownRejected"
+
+	// ReasonNodeCountReached is the reason of the ScalingInProgress condition when an Aerospike
+	// cluster has the requested number of nodes
+	ReasonNodeCountReached = "NodeCountReached"
+
+	// ReasonConfigOutdated is the reason of the ConfigApplying condition when some pods of an
+	// Aerospike cluster are running an outdated configuration
+	ReasonConfigOutdated = "ConfigOutdated"
+
+	// ReasonConfigApplied is the reason of the ConfigApplying condition when every pod of an
+	// Aerospike cluster is running the current configuration
+	ReasonConfigApplied = "ConfigApplied"
+
+	// ReasonSuperseded is the reason of a condition which has been set to False because the
+	// operation it reports on has been superseded by another one
+	ReasonSuperseded = "Superseded"
+
 	// DefaultSecretFilename represents the name of the file that is required to exist
 	// in the secret referenced in BackupStorageSpec objects.
 	DefaultSecretFilename = "key.json"
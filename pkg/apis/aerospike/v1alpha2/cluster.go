@@ -53,6 +53,36 @@ type AerospikeClusterSpec struct {
 	BackupSpec *AerospikeClusterBackupSpec `json:"backupSpec,omitempty"`
 	// Define resources requests and limits for Aerospike Server Container.
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// The specification of how data in the Aerospike cluster should be replicated to remote datacenters (XDR).
+	// +optional
+	XDR *XDRSpec `json:"xdr,omitempty"`
+	// The specification of the container images used to run the Aerospike cluster.
+	// If absent, the defaults configured in aerospike-operator will be used.
+	// +optional
+	Image *ImageSpec `json:"image,omitempty"`
+	// Customizations to apply to the pods that make up the Aerospike cluster.
+	// +optional
+	PodSpec *AerospikePodSpec `json:"podSpec,omitempty"`
+	// The specification of how pods are restarted when the configuration, the pod customizations or the image of the
+	// Aerospike cluster change.
+	// +optional
+	RolloutStrategy *RolloutStrategySpec `json:"rolloutStrategy,omitempty"`
+	// Whether reconciliation of the Aerospike cluster is paused, in which case aerospike-operator does not act upon
+	// changes to its spec nor manage its pods (but still updates its status).
+	// Defaults to false.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+	// The specification of how aerospike-operator recovers from a failed version upgrade.
+	// If absent, a failed upgrade requires manual intervention.
+	// +optional
+	UpgradeRecovery *UpgradeRecoverySpec `json:"upgradeRecovery,omitempty"`
+	// The specification of how version upgrades are validated on a subset of the pods before upgrading the remaining
+	// ones. If absent, every pod is upgraded in turn.
+	// +optional
+	CanaryUpgrade *CanaryUpgradeSpec `json:"canaryUpgrade,omitempty"`
+	// The specification of how the Aerospike cluster is monitored with Prometheus.
+	// +optional
+	Monitoring *MonitoringSpec `json:"monitoring,omitempty"`
 }
 
 // AerospikeClusterStatus represents the current state of an Aerospike cluster.
@@ -61,7 +91,74 @@ type AerospikeClusterStatus struct {
 	AerospikeClusterSpec
 	// Details about the current condition of the AerospikeCluster resource.
 	// +k8s:openapi-gen=false
-	Conditions []apiextensions.CustomResourceDefinitionCondition `json:"conditions"`
+	Conditions []AerospikeClusterCondition `json:"conditions"`
+	// The observed state of cross-datacenter replication towards each remote datacenter.
+	// +optional
+	XDRDestinations []XDRDestinationStatus `json:"xdrDestinations,omitempty"`
+	// The plan being carried out in order to upgrade the Aerospike cluster to the requested version when the upgrade
+	// must go through intermediate versions.
+	// +optional
+	UpgradePlan *UpgradePlanStatus `json:"upgradePlan,omitempty"`
+	// The number of pods in the Aerospike cluster which are running and ready.
+	ReadyNodeCount int32 `json:"readyNodeCount"`
+	// The observed state of each Aerospike node.
+	// +optional
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// The cluster key reported by the Aerospike nodes, or empty if they do not agree on one.
+	// +optional
+	ClusterKey string `json:"clusterKey,omitempty"`
+	// Whether every Aerospike node reports cluster integrity, agrees on the cluster key and sees every other node.
+	ClusterIntegrity bool `json:"clusterIntegrity"`
+	// The observed usage of each Aerospike namespace, aggregated across all Aerospike nodes.
+	// +optional
+	NamespaceStatistics []NamespaceStatistics `json:"namespaceStatistics,omitempty"`
+	// The time at which the state of the Aerospike nodes was last observed.
+	// +optional
+	LastObservedTime *metav1.Time `json:"lastObservedTime,omitempty"`
+}
+
+// NodeStatus represents the observed state of an Aerospike node.
+type NodeStatus struct {
+	// The name of the pod running the Aerospike node.
+	PodName string `json:"podName"`
+	// The IP address of the pod running the Aerospike node.
+	// +optional
+	IP string `json:"ip,omitempty"`
+	// The id of the Aerospike node.
+	// +optional
+	NodeID string `json:"nodeId,omitempty"`
+	// The build (version) of Aerospike reported by the Aerospike node.
+	// +optional
+	Build string `json:"build,omitempty"`
+	// Whether the pod running the Aerospike node is running and ready.
+	Ready bool `json:"ready"`
+	// The number of partitions which the Aerospike node has yet to migrate.
+	MigratePartitionsRemaining int64 `json:"migratePartitionsRemaining"`
+}
+
+// NamespaceStatistics represents the observed usage of an Aerospike namespace, aggregated across all Aerospike nodes.
+type NamespaceStatistics struct {
+	// The name of the Aerospike namespace.
+	Name string `json:"name"`
+	// The number of (master) objects stored in the Aerospike namespace.
+	Objects int64 `json:"objects"`
+	// The amount of memory (bytes) used by the Aerospike namespace.
+	MemoryUsedBytes int64 `json:"memoryUsedBytes"`
+	// The amount of memory (bytes) available to the Aerospike namespace.
+	MemoryTotalBytes int64 `json:"memoryTotalBytes"`
+	// The amount of disk space (bytes) used by the Aerospike namespace.
+	DiskUsedBytes int64 `json:"diskUsedBytes"`
+	// The amount of disk space (bytes) available to the Aerospike namespace.
+	DiskTotalBytes int64 `json:"diskTotalBytes"`
+}
+
+// UpgradePlanStatus represents the plan being carried out in order to upgrade an Aerospike cluster.
+type UpgradePlanStatus struct {
+	// The versions the Aerospike cluster goes through, starting with the version it was running when the upgrade
+	// was planned and ending with the requested version.
+	Versions []string `json:"versions"`
+	// The index in versions of the version the Aerospike cluster is currently being upgraded to.
+	CurrentHop int32 `json:"currentHop"`
 }
 
 // AerospikeNamespaceSpec specifies the configuration for an Aerospike namespace.
@@ -98,10 +195,17 @@ type AerospikeClusterBackupSpec struct {
 
 // StorageSpec specifies how data in a given Aerospike namespace will be stored.
 type StorageSpec struct {
-	// The storage engine to be used for the namespace (file or device).
+	// The storage engine to be used for the namespace (file, device or memory).
 	Type string `json:"type"`
 	// The size (gibibytes) of the persistent volume to use for storing data in this namespace, suffixed with G.
-	Size string `json:"size"`
+	// If volumeCount is greater than one, this is the size of each persistent volume.
+	// Required unless type is memory.
+	// +optional
+	Size string `json:"size,omitempty"`
+	// The number of persistent volumes (devices or files) across which to stripe data in this namespace.
+	// Defaults to 1.
+	// +optional
+	VolumeCount *int32 `json:"volumeCount,omitempty"`
 	// The name of the storage class to use to create persistent volumes.
 	// +optional
 	StorageClassName *string `json:"storageClassName,omitempty"`
@@ -110,10 +214,247 @@ type StorageSpec struct {
 	// kept forever.
 	// +optional
 	PersistentVolumeClaimTTL *string `json:"persistentVolumeClaimTTL,omitempty"`
+	// The period (e.g. 30m) after which the PVCs of a pod that cannot be scheduled because the node holding its local
+	// persistent volumes is gone are released, so that the pod can be re-created with new PVCs. Defaults to 30m. A
+	// value of 0m disables releasing PVCs.
+	// +optional
+	LocalVolumeReleaseTimeout *string `json:"localVolumeReleaseTimeout,omitempty"`
 	// Whether to always keep an in-memory copy of all data in this Aerospike
 	// namespace.
 	// +optional
 	DataInMemory *bool `json:"dataInMemory,omitempty"`
+	// Specifies the shadow devices to which data written to the devices of this namespace is mirrored.
+	// Only valid when type is device.
+	// +optional
+	Shadow *ShadowStorageSpec `json:"shadow,omitempty"`
+}
+
+// ShadowStorageSpec specifies the shadow devices to which data written to the devices of an Aerospike namespace is
+// mirrored (e.g. network-attached persistent disks backing local SSDs). A shadow device having the same size as the
+// namespace's devices is created for each of them.
+type ShadowStorageSpec struct {
+	// The name of the storage class to use to create the persistent volumes backing the shadow devices.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+}
+
+// ImageSpec specifies the container images used to run an Aerospike cluster and how they should be pulled.
+type ImageSpec struct {
+	// The repository of the Aerospike server image (e.g. registry.example.com/aerospike/aerospike-server).
+	// The image tag is always set to the value of version. If a tag is included, it must be equal to version.
+	// +optional
+	Server string `json:"server,omitempty"`
+	// The repository of the aerospike-operator-tools image (e.g. registry.example.com/travelaudience/aerospike-operator-tools).
+	// The image tag is always set to the version of aerospike-operator.
+	// +optional
+	Tools string `json:"tools,omitempty"`
+	// The pull policy to use for all containers.
+	// +optional
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+	// The list of secrets to use for pulling images from private registries.
+	// +optional
+	PullSecrets []corev1.LocalObjectReference `json:"pullSecrets,omitempty"`
+}
+
+// RolloutStrategySpec specifies how the pods that make up an Aerospike cluster are restarted when its configuration,
+// pod customizations or image change. Rack-aware restarts are not supported.
+type RolloutStrategySpec struct {
+	// The maximum number of pods that may be restarted at the same time. Must be smaller than the node count and
+	// than the replication factor of every namespace. Defaults to 1.
+	// +optional
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+	// The order in which pods are restarted according to their index (Ascending or Descending).
+	// Defaults to Ascending.
+	// +optional
+	Order string `json:"order,omitempty"`
+	// The period to wait (e.g. 30s) after a group of pods has been restarted before restarting the next one.
+	// Defaults to 0s.
+	// +optional
+	PauseBetweenPods *string `json:"pauseBetweenPods,omitempty"`
+}
+
+// UpgradeRecoverySpec specifies how aerospike-operator recovers from a failed version upgrade.
+type UpgradeRecoverySpec struct {
+	// The action to perform when a version upgrade has failed (Rollback or Retry). Rollback rolls the Aerospike cluster
+	// back to the version it was running before the upgrade and restores the backup made before the upgrade, while
+	// .spec.version is left unchanged. Retry retries the upgrade once (including an upgrade that has been rolled back).
+	Action string `json:"action"`
+}
+
+// CanaryUpgradeSpec specifies how version upgrades are validated on a subset of the pods that make up an Aerospike
+// cluster (the canaries) before the remaining pods are upgraded.
+type CanaryUpgradeSpec struct {
+	// The number of pods to upgrade before holding for the soak period. Must be smaller than the node count.
+	// Defaults to 1.
+	// +optional
+	PodCount *int32 `json:"podCount,omitempty"`
+	// The period (e.g. 10m) during which the health of the upgraded pods is checked before the remaining pods are
+	// upgraded.
+	// Defaults to 10m.
+	// +optional
+	SoakPeriod *string `json:"soakPeriod,omitempty"`
+	// The maximum number of client errors that the upgraded pods may report during the soak period.
+	// Defaults to 0.
+	// +optional
+	MaxClientErrors *int64 `json:"maxClientErrors,omitempty"`
+}
+
+// MonitoringSpec specifies how an Aerospike cluster is monitored with Prometheus.
+type MonitoringSpec struct {
+	// The specification of the asprom sidecar which exports the metrics of each Aerospike node.
+	// +optional
+	Exporter *ExporterSpec `json:"exporter,omitempty"`
+	// The specification of the ServiceMonitor resource used by the Prometheus Operator to scrape the Aerospike
+	// cluster. If absent, no ServiceMonitor resource is created.
+	// +optional
+	ServiceMonitor *ServiceMonitorSpec `json:"serviceMonitor,omitempty"`
+	// The specification of the PrometheusRule resource holding the alerting rules for the Aerospike cluster.
+	// If absent, no PrometheusRule resource is created.
+	// +optional
+	PrometheusRule *PrometheusRuleSpec `json:"prometheusRule,omitempty"`
+}
+
+// ExporterSpec specifies the asprom sidecar which exports the metrics of each Aerospike node.
+type ExporterSpec struct {
+	// The image of the asprom sidecar (e.g. registry.example.com/alicebob/asprom:latest).
+	// Defaults to the aerospike-operator-tools image.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// The resource requests and limits of the asprom sidecar.
+	// Defaults to requesting 10m of CPU and 32Mi of memory, and to limiting these to 10m and 64Mi.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ServiceMonitorSpec specifies the ServiceMonitor resource used by the Prometheus Operator to scrape an Aerospike
+// cluster.
+type ServiceMonitorSpec struct {
+	// Additional labels to add to the ServiceMonitor resource (e.g. to match the serviceMonitorSelector of a
+	// Prometheus resource).
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// The interval (e.g. 30s) at which the asprom sidecars are scraped.
+	// Defaults to the interval configured in Prometheus.
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// The timeout (e.g. 10s) for scraping the asprom sidecars. Must not be greater than interval.
+	// Defaults to the timeout configured in Prometheus.
+	// +optional
+	ScrapeTimeout string `json:"scrapeTimeout,omitempty"`
+}
+
+// PrometheusRuleSpec specifies the PrometheusRule resource holding the alerting rules for an Aerospike cluster.
+type PrometheusRuleSpec struct {
+	// Additional labels to add to the PrometheusRule resource (e.g. to match the ruleSelector of a Prometheus
+	// resource).
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// The period (e.g. 30m) after which migrations that are still in progress are considered to be stuck.
+	// Defaults to 30m.
+	// +optional
+	MigrationsStuckAfter *string `json:"migrationsStuckAfter,omitempty"`
+}
+
+// AerospikePodSpec specifies customizations to apply to the pods that make up an Aerospike cluster.
+type AerospikePodSpec struct {
+	// Additional labels to add to each pod.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Additional annotations to add to each pod.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// The node selector to use when scheduling pods.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// The tolerations to add to each pod.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Additional scheduling constraints for each pod.
+	// These are merged with the pod anti-affinity rule that prevents two pods of the same cluster from running on the same node.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// The name of the priority class to use for each pod.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Additional environment variables to set in the Aerospike server container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// Additional containers to run in each pod.
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+	// Additional volumes to add to each pod (e.g. for use by sidecars).
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// The security context to use for each pod.
+	// +optional
+	SecurityContext *corev1.PodSecurityContext `json:"securityContext,omitempty"`
+}
+
+// XDRSpec specifies how data in the Aerospike cluster should be replicated to remote datacenters.
+// XDR is only available in Aerospike Enterprise, so an Enterprise image must be specified in .spec.image.server.
+type XDRSpec struct {
+	// The size (gibibytes) of the digest log used to keep track of the records that must be shipped, suffixed with G.
+	// Defaults to 1G.
+	// +optional
+	DigestLogSize *string `json:"digestLogSize,omitempty"`
+	// The list of remote datacenters to which data should be shipped.
+	Destinations []XDRDestinationSpec `json:"destinations"`
+}
+
+// XDRDestinationSpec specifies a remote datacenter to which data should be shipped.
+type XDRDestinationSpec struct {
+	// The name of the remote datacenter.
+	Name string `json:"name"`
+	// A reference to an AerospikeCluster resource acting as the remote datacenter.
+	// Exactly one of cluster and seedAddresses must be specified.
+	// +optional
+	Cluster *XDRClusterReference `json:"cluster,omitempty"`
+	// The list of addresses (host:port) of nodes in the remote datacenter.
+	// Exactly one of cluster and seedAddresses must be specified.
+	// +optional
+	SeedAddresses []string `json:"seedAddresses,omitempty"`
+	// The names of the Aerospike namespaces to be shipped to the remote datacenter.
+	Namespaces []string `json:"namespaces"`
+}
+
+// XDRClusterReference references an AerospikeCluster resource acting as a remote datacenter.
+type XDRClusterReference struct {
+	// The name of the AerospikeCluster resource.
+	Name string `json:"name"`
+	// The Kubernetes namespace of the AerospikeCluster resource.
+	// Defaults to the namespace of the current AerospikeCluster resource.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// AerospikeClusterCondition represents a condition of an AerospikeCluster resource.
+type AerospikeClusterCondition struct {
+	// The type of the condition.
+	Type apiextensions.CustomResourceDefinitionConditionType `json:"type"`
+	// The status of the condition, one of True, False or Unknown.
+	Status apiextensions.ConditionStatus `json:"status"`
+	// The generation of the AerospikeCluster resource the condition was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// The last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// A unique, one-word, CamelCase reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human-readable message indicating details about the last transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// XDRDestinationStatus represents the observed state of replication towards a remote datacenter.
+type XDRDestinationStatus struct {
+	// The name of the remote datacenter.
+	Name string `json:"name"`
+	// The state of the link to the remote datacenter as reported by Aerospike (e.g. CLUSTER_UP).
+	State string `json:"state,omitempty"`
+	// The highest replication lag (seconds) reported by the nodes in the Aerospike cluster.
+	Lag int64 `json:"lag"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -36,6 +36,7 @@ import (
 	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/metrics"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
 )
 
@@ -157,15 +158,18 @@ func (h *AerospikeBackupRestoreHandler) launchJob(obj aerospikev1alpha2.BackupRe
 // resource's conditions.
 func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2.BackupRestoreObject, job *batch.Job) {
 	var jobCondition batch.JobConditionType
+	var finishedAt metav1.Time
 
 	// look for the complete or failed condition in the associated job
 	for _, c := range job.Status.Conditions {
 		if c.Type == batch.JobComplete && c.Status == v1.ConditionTrue {
 			jobCondition = batch.JobComplete
+			finishedAt = c.LastTransitionTime
 			break
 		}
 		if c.Type == batch.JobFailed && c.Status == v1.ConditionTrue {
 			jobCondition = batch.JobFailed
+			finishedAt = c.LastTransitionTime
 			break
 		}
 	}
@@ -178,6 +182,8 @@ func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2
 			logfields.Kind: obj.GetKind(),
 			logfields.Key:  meta.Key(obj),
 		}).Debugf("%s job has finished", obj.GetOperationType())
+		// record the outcome and duration of the job
+		metrics.ObserveBackupRestoreJob(string(obj.GetOperationType()), metrics.ResultSucceeded, jobDuration(job, finishedAt))
 		// record an event indicating success
 		h.recorder.Eventf(obj.(runtime.Object), v1.EventTypeNormal, events.ReasonJobFinished,
 			"%s job has finished", obj.GetOperationType())
@@ -194,6 +200,8 @@ func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2
 			logfields.Kind: obj.GetKind(),
 			logfields.Key:  meta.Key(obj),
 		}).Debugf("%s job failed %d times", obj.GetOperationType(), job.Status.Failed)
+		// record the outcome and duration of the job
+		metrics.ObserveBackupRestoreJob(string(obj.GetOperationType()), metrics.ResultFailed, jobDuration(job, finishedAt))
 		// record an event indicating failure
 		h.recorder.Eventf(obj.(runtime.Object), v1.EventTypeWarning, events.ReasonJobFailed,
 			"%s job failed %d times", obj.GetOperationType(), job.Status.Failed)
@@ -206,3 +214,16 @@ func (h *AerospikeBackupRestoreHandler) maybeSetConditions(obj aerospikev1alpha2
 		}))
 	}
 }
+
+// jobDuration returns the time elapsed between the start of job and the
+// specified finish time.
+func jobDuration(job *batch.Job, finishedAt metav1.Time) time.Duration {
+	startedAt := job.CreationTimestamp
+	if job.Status.StartTime != nil {
+		startedAt = *job.Status.StartTime
+	}
+	if finishedAt.IsZero() {
+		finishedAt = metav1.Now()
+	}
+	return finishedAt.Sub(startedAt.Time)
+}
@@ -22,15 +22,16 @@ import (
 	log "github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
-	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 const (
@@ -45,6 +46,14 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 	if _, ok := secret.Data[secretKey]; !ok {
 		return nil, fmt.Errorf("secret does not contain expected field %q", secretKey)
 	}
+	// use the image settings of the target cluster if it exists, falling back
+	// to the defaults otherwise
+	var imageSpec *aerospikev1alpha2.ImageSpec
+	if aerospikeCluster, err := h.aerospikeClustersLister.AerospikeClusters(obj.GetNamespace()).Get(obj.GetTarget().Cluster); err == nil {
+		imageSpec = aerospikeCluster.Spec.Image
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
 	job := batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: h.getJobName(obj),
@@ -75,8 +84,8 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 					Containers: []corev1.Container{
 						{
 							Name:            "aerospike-operator-tools",
-							Image:           fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-							ImagePullPolicy: corev1.PullAlways,
+							Image:           images.ToolsImage(imageSpec),
+							ImagePullPolicy: images.GetPullPolicy(imageSpec, corev1.PullAlways),
 							Command: []string{
 								"backup",
 								string(obj.GetOperationType()),
@@ -96,7 +105,8 @@ func (h *AerospikeBackupRestoreHandler) createJob(obj aerospikev1alpha2.BackupRe
 							},
 						},
 					},
-					RestartPolicy: corev1.RestartPolicyNever,
+					ImagePullSecrets: images.GetPullSecrets(imageSpec),
+					RestartPolicy:    corev1.RestartPolicyNever,
 					Volumes: []corev1.Volume{
 						{
 							Name: secretVolumeName,
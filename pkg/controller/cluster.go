@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
@@ -52,6 +53,7 @@ type AerospikeClusterController struct {
 func NewAerospikeClusterController(
 	kubeClient kubernetes.Interface,
 	aerospikeClient aerospikeclientset.Interface,
+	dynamicClient dynamic.Interface,
 	kubeInformerFactory kubeinformers.SharedInformerFactory,
 	aerospikeInformerFactory aerospikeinformers.SharedInformerFactory) *AerospikeClusterController {
 
@@ -60,6 +62,8 @@ func NewAerospikeClusterController(
 	configMapInformer := kubeInformerFactory.Core().V1().ConfigMaps()
 	serviceInformer := kubeInformerFactory.Core().V1().Services()
 	pvcInformer := kubeInformerFactory.Core().V1().PersistentVolumeClaims()
+	pvInformer := kubeInformerFactory.Core().V1().PersistentVolumes()
+	nodeInformer := kubeInformerFactory.Core().V1().Nodes()
 	scInformer := kubeInformerFactory.Storage().V1().StorageClasses()
 	aerospikeClusterInformer := aerospikeInformerFactory.Aerospike().V1alpha2().AerospikeClusters()
 	aerospikeNamespaceBackupInformer := aerospikeInformerFactory.Aerospike().V1alpha2().AerospikeNamespaceBackups()
@@ -69,6 +73,8 @@ func NewAerospikeClusterController(
 	configMapsLister := configMapInformer.Lister()
 	servicesLister := serviceInformer.Lister()
 	pvcsLister := pvcInformer.Lister()
+	pvsLister := pvInformer.Lister()
+	nodesLister := nodeInformer.Lister()
 	scsLister := scInformer.Lister()
 	aerospikeClustersLister := aerospikeClusterInformer.Lister()
 	aerospikeNamespaceBackupsLister := aerospikeNamespaceBackupInformer.Lister()
@@ -82,11 +88,13 @@ func NewAerospikeClusterController(
 		configMapInformer.Informer().HasSynced,
 		serviceInformer.Informer().HasSynced,
 		pvcInformer.Informer().HasSynced,
+		pvInformer.Informer().HasSynced,
+		nodeInformer.Informer().HasSynced,
 		scInformer.Informer().HasSynced,
 		aerospikeClusterInformer.Informer().HasSynced,
 	}
 	c.syncHandler = c.processQueueItem
-	c.reconciler = reconciler.New(kubeClient, aerospikeClient, podsLister, configMapsLister, servicesLister, pvcsLister, scsLister, aerospikeNamespaceBackupsLister, c.recorder)
+	c.reconciler = reconciler.New(kubeClient, aerospikeClient, dynamicClient, podsLister, configMapsLister, servicesLister, pvcsLister, pvsLister, nodesLister, scsLister, aerospikeNamespaceBackupsLister, c.recorder)
 
 	c.logger.Debug("setting up event handlers")
 
@@ -142,6 +150,12 @@ func (c *AerospikeClusterController) processQueueItem(key string) error {
 		return err
 	}
 
+	// if reconciliation is paused, only update the status of the resource.
+	// deepcopy aerospikeCluster so we don't possibly mutate the cache
+	if aerospikeCluster.Spec.Paused {
+		return c.reconciler.UpdatePausedStatus(aerospikeCluster.DeepCopy())
+	}
+
 	// deepcopy aerospikeCluster before reconciling so we don't possibly mutate the cache
 	return c.reconciler.MaybeReconcile(aerospikeCluster.DeepCopy())
 }
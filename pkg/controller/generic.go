@@ -30,6 +30,9 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/metrics"
 )
 
 // Controller encapsulates a controller for Kubernetes resources.
@@ -40,6 +43,8 @@ type Controller interface {
 
 // genericController contains basic functionality that is generic to all controllers
 type genericController struct {
+	// name is the name of the controller
+	name string
 	// logger is the logger that the controller will use
 	logger log.FieldLogger
 
@@ -71,6 +76,7 @@ func newGenericController(name string, threadiness int, kubeClient kubernetes.In
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: name})
 
 	return &genericController{
+		name:        name,
 		logger:      logger,
 		workqueue:   workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), name),
 		recorder:    recorder,
@@ -151,7 +157,19 @@ func (c *genericController) processNextWorkItem() bool {
 		}
 		// Run the syncHandler, passing it the namespace/name string of the
 		// AerospikeCluster resource to be synced.
-		if err := c.syncHandler(key); err != nil {
+		start := time.Now()
+		err := c.syncHandler(key)
+		// the resource may have requested to be processed again after a
+		// delay, which is not a failure
+		if delay, ok := errors.IsRequeueAfter(err); ok {
+			metrics.ObserveReconcile(c.name, time.Since(start), nil)
+			c.workqueue.Forget(obj)
+			c.workqueue.AddAfter(key, delay)
+			c.logger.Debugf("successfully synced '%s', requeuing after %s", key, delay)
+			return nil
+		}
+		metrics.ObserveReconcile(c.name, time.Since(start), err)
+		if err != nil {
 			return fmt.Errorf("error syncing '%s': %s", key, err.Error())
 		}
 		// Finally, if no error occurs we Forget this item so it does not
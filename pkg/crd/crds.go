@@ -19,6 +19,7 @@ package crd
 import (
 	"fmt"
 
+	corev1 "k8s.io/api/core/v1"
 	extsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -45,6 +46,11 @@ const (
 	// ttlPattern is the regex used to match a number of days (with
 	// optional fraction) suffixed with a "d"
 	ttlPattern = `^([0-9]*[.])?[0-9]+d$`
+	// durationPattern is the regex used to validate durations such as 30m or 1.5h
+	durationPattern = `^([0-9]*[.])?[0-9]+(s|m|h|d)$`
+	// prometheusDurationPattern is the regex used to validate durations
+	// understood by both prometheus and aerospike-operator, such as 30s or 5m
+	prometheusDurationPattern = `^[0-9]+(ms|s|m|h|d)$`
 )
 
 var (
@@ -176,12 +182,18 @@ var (
 																Enum: []extsv1beta1.JSON{
 																	{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypeFile))},
 																	{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypeDevice))},
+																	{Raw: []byte(asstrings.DoubleQuoted(common.StorageTypeMemory))},
 																},
 															},
 															"size": {
 																Type:    "string",
 																Pattern: `^(20{3}|1?\d{1,3}|[1-9])G$`,
 															},
+															"volumeCount": {
+																Type:    "integer",
+																Minimum: pointers.NewFloat64(1),
+																Maximum: pointers.NewFloat64(26),
+															},
 															"storageClassName": {
 																Type: "string",
 															},
@@ -189,13 +201,24 @@ var (
 																Type:    "string",
 																Pattern: ttlPattern,
 															},
+															"localVolumeReleaseTimeout": {
+																Type:    "string",
+																Pattern: durationPattern,
+															},
 															"dataInMemory": {
 																Type: "boolean",
 															},
+															"shadow": {
+																Type: "object",
+																Properties: map[string]extsv1beta1.JSONSchemaProps{
+																	"storageClassName": {
+																		Type: "string",
+																	},
+																},
+															},
 														},
 														Required: []string{
 															"type",
-															"size",
 														},
 													},
 												},
@@ -219,6 +242,246 @@ var (
 											"storage",
 										},
 									},
+									"xdr": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"digestLogSize": {
+												Type:    "string",
+												Pattern: `^[1-9]\d*G$`,
+											},
+											"destinations": {
+												Type:     "array",
+												MinItems: pointers.NewInt64(1),
+												Items: &extsv1beta1.JSONSchemaPropsOrArray{
+													Schema: &extsv1beta1.JSONSchemaProps{
+														Title: "destination",
+														Type:  "object",
+														Properties: map[string]extsv1beta1.JSONSchemaProps{
+															"name": {
+																Type:    "string",
+																Pattern: `^[a-zA-Z0-9_-]{1,31}$`,
+															},
+															"cluster": {
+																Type: "object",
+																Properties: map[string]extsv1beta1.JSONSchemaProps{
+																	"name": {
+																		Type:      "string",
+																		MinLength: pointers.NewInt64(1),
+																	},
+																	"namespace": {
+																		Type:      "string",
+																		MinLength: pointers.NewInt64(1),
+																	},
+																},
+																Required: []string{
+																	"name",
+																},
+															},
+															"seedAddresses": {
+																Type: "array",
+																Items: &extsv1beta1.JSONSchemaPropsOrArray{
+																	Schema: &extsv1beta1.JSONSchemaProps{
+																		Type:      "string",
+																		MinLength: pointers.NewInt64(1),
+																	},
+																},
+															},
+															"namespaces": {
+																Type:     "array",
+																MinItems: pointers.NewInt64(1),
+																Items: &extsv1beta1.JSONSchemaPropsOrArray{
+																	Schema: &extsv1beta1.JSONSchemaProps{
+																		Type:      "string",
+																		MinLength: pointers.NewInt64(1),
+																	},
+																},
+															},
+														},
+														Required: []string{
+															"name",
+															"namespaces",
+														},
+													},
+												},
+											},
+										},
+										Required: []string{
+											"destinations",
+										},
+									},
+									"podSpec": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"labels": {
+												Type: "object",
+											},
+											"annotations": {
+												Type: "object",
+											},
+											"nodeSelector": {
+												Type: "object",
+											},
+											"tolerations": {
+												Type: "array",
+											},
+											"affinity": {
+												Type: "object",
+											},
+											"priorityClassName": {
+												Type: "string",
+											},
+											"env": {
+												Type: "array",
+											},
+											"sidecars": {
+												Type: "array",
+											},
+											"volumes": {
+												Type: "array",
+											},
+											"securityContext": {
+												Type: "object",
+											},
+										},
+									},
+									"paused": {
+										Type: "boolean",
+									},
+									"canaryUpgrade": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"podCount": {
+												Type:    "integer",
+												Minimum: pointers.NewFloat64(1),
+											},
+											"soakPeriod": {
+												Type:    "string",
+												Pattern: durationPattern,
+											},
+											"maxClientErrors": {
+												Type:    "integer",
+												Minimum: pointers.NewFloat64(0),
+											},
+										},
+									},
+									"monitoring": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"exporter": {
+												Type: "object",
+												Properties: map[string]extsv1beta1.JSONSchemaProps{
+													"image": {
+														Type:      "string",
+														MinLength: pointers.NewInt64(1),
+													},
+													"resources": {
+														Type: "object",
+													},
+												},
+											},
+											"serviceMonitor": {
+												Type: "object",
+												Properties: map[string]extsv1beta1.JSONSchemaProps{
+													"labels": {
+														Type: "object",
+													},
+													"interval": {
+														Type:    "string",
+														Pattern: prometheusDurationPattern,
+													},
+													"scrapeTimeout": {
+														Type:    "string",
+														Pattern: prometheusDurationPattern,
+													},
+												},
+											},
+											"prometheusRule": {
+												Type: "object",
+												Properties: map[string]extsv1beta1.JSONSchemaProps{
+													"labels": {
+														Type: "object",
+													},
+													"migrationsStuckAfter": {
+														Type:    "string",
+														Pattern: prometheusDurationPattern,
+													},
+												},
+											},
+										},
+									},
+									"upgradeRecovery": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"action": {
+												Type: "string",
+												Enum: []extsv1beta1.JSON{
+													{Raw: []byte(asstrings.DoubleQuoted(common.UpgradeRecoveryActionRollback))},
+													{Raw: []byte(asstrings.DoubleQuoted(common.UpgradeRecoveryActionRetry))},
+												},
+											},
+										},
+										Required: []string{
+											"action",
+										},
+									},
+									"rolloutStrategy": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"maxUnavailable": {
+												Type:    "integer",
+												Minimum: pointers.NewFloat64(1),
+											},
+											"order": {
+												Type: "string",
+												Enum: []extsv1beta1.JSON{
+													{Raw: []byte(asstrings.DoubleQuoted(common.RolloutOrderAscending))},
+													{Raw: []byte(asstrings.DoubleQuoted(common.RolloutOrderDescending))},
+												},
+											},
+											"pauseBetweenPods": {
+												Type:    "string",
+												Pattern: durationPattern,
+											},
+										},
+									},
+									"image": {
+										Type: "object",
+										Properties: map[string]extsv1beta1.JSONSchemaProps{
+											"server": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"tools": {
+												Type:      "string",
+												MinLength: pointers.NewInt64(1),
+											},
+											"pullPolicy": {
+												Type: "string",
+												Enum: []extsv1beta1.JSON{
+													{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullAlways)))},
+													{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullIfNotPresent)))},
+													{Raw: []byte(asstrings.DoubleQuoted(string(corev1.PullNever)))},
+												},
+											},
+											"pullSecrets": {
+												Type: "array",
+												Items: &extsv1beta1.JSONSchemaPropsOrArray{
+													Schema: &extsv1beta1.JSONSchemaProps{
+														Type: "object",
+														Properties: map[string]extsv1beta1.JSONSchemaProps{
+															"name": {
+																Type:      "string",
+																MinLength: pointers.NewInt64(1),
+															},
+														},
+														Required: []string{
+															"name",
+														},
+													},
+												},
+											},
+										},
+									},
 								},
 								Required: []string{
 									"nodeCount",
@@ -250,6 +513,25 @@ var (
 						Description: "The number of nodes in the Aerospike cluster",
 						JSONPath:    ".status.nodeCount",
 					},
+					{
+						Name:        "Ready",
+						Type:        "integer",
+						Description: "The number of nodes in the Aerospike cluster which are ready",
+						JSONPath:    ".status.readyNodeCount",
+					},
+					{
+						Name:        "Integrity",
+						Type:        "boolean",
+						Description: "Whether every node in the Aerospike cluster reports cluster integrity",
+						JSONPath:    ".status.clusterIntegrity",
+					},
+					{
+						Name:        "Cluster Key",
+						Type:        "string",
+						Description: "The cluster key agreed upon by every node in the Aerospike cluster",
+						JSONPath:    ".status.clusterKey",
+						Priority:    1,
+					},
 					{
 						Name:        "Age",
 						Type:        "date",
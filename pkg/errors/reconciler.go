@@ -16,9 +16,34 @@ limitations under the License.
 
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 var (
-	PodUpgradeFailed    = fmt.Errorf("pod upgrade failed")
-	ClusterBackupFailed = fmt.Errorf("cluster backup failed")
+	PodUpgradeFailed     = fmt.Errorf("pod upgrade failed")
+	ClusterBackupFailed  = fmt.Errorf("cluster backup failed")
+	ClusterRestoreFailed = fmt.Errorf("cluster restore failed")
+	UpgradePaused        = fmt.Errorf("upgrade paused")
 )
+
+// RequeueAfter is returned when a resource must be processed again after the
+// specified delay, so that waiting for a period of time to elapse does not
+// block a worker. It does not indicate a failure.
+type RequeueAfter struct {
+	Delay time.Duration
+}
+
+func (e *RequeueAfter) Error() string {
+	return fmt.Sprintf("requeue after %s", e.Delay)
+}
+
+// IsRequeueAfter returns the delay after which a resource must be processed
+// again if err is a RequeueAfter error.
+func IsRequeueAfter(err error) (time.Duration, bool) {
+	if e, ok := err.(*RequeueAfter); ok {
+		return e.Delay, true
+	}
+	return 0, false
+}
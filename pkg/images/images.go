@@ -0,0 +1,110 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+const (
+	// DefaultServerRepository is the repository of the aerospike server image
+	// used when none is specified.
+	DefaultServerRepository = "aerospike/aerospike-server"
+	// DefaultToolsRepository is the repository of the aerospike-operator-tools
+	// image used when none is specified.
+	DefaultToolsRepository = "quay.io/travelaudience/aerospike-operator-tools"
+)
+
+var (
+	// ServerRepository is the repository of the aerospike server image used
+	// for aerospikecluster resources that do not specify one.
+	ServerRepository = DefaultServerRepository
+	// ToolsRepository is the repository of the aerospike-operator-tools image
+	// used for aerospikecluster resources that do not specify one.
+	ToolsRepository = DefaultToolsRepository
+	// PullPolicy is the pull policy used for aerospikecluster resources that do
+	// not specify one. If empty, the per-container defaults are used.
+	PullPolicy string
+	// PullSecrets is the list of names of the secrets used for pulling images
+	// for aerospikecluster resources that do not specify any.
+	PullSecrets []string
+)
+
+// SplitImage splits the specified image reference into its repository and tag.
+// The tag is empty if image does not include one.
+func SplitImage(image string) (string, string) {
+	// a colon before the last slash separates the registry host from its port
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.LastIndex(image, "/") > i {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
+
+// ServerImage returns the aerospike server image to use for the specified
+// image spec and version of aerospike.
+func ServerImage(spec *aerospikev1alpha2.ImageSpec, version string) string {
+	repository := ServerRepository
+	if spec != nil && spec.Server != "" {
+		repository, _ = SplitImage(spec.Server)
+	}
+	return fmt.Sprintf("%s:%s", repository, version)
+}
+
+// ToolsImage returns the aerospike-operator-tools image to use for the
+// specified image spec.
+func ToolsImage(spec *aerospikev1alpha2.ImageSpec) string {
+	repository := ToolsRepository
+	if spec != nil && spec.Tools != "" {
+		repository = spec.Tools
+	}
+	return fmt.Sprintf("%s:%s", repository, versioning.OperatorVersion)
+}
+
+// GetPullPolicy returns the pull policy to use for the specified image spec,
+// falling back to def if no pull policy has been configured.
+func GetPullPolicy(spec *aerospikev1alpha2.ImageSpec, def corev1.PullPolicy) corev1.PullPolicy {
+	if spec != nil && spec.PullPolicy != "" {
+		return spec.PullPolicy
+	}
+	if PullPolicy != "" {
+		return corev1.PullPolicy(PullPolicy)
+	}
+	return def
+}
+
+// GetPullSecrets returns the list of secrets to use for pulling images for the
+// specified image spec.
+func GetPullSecrets(spec *aerospikev1alpha2.ImageSpec) []corev1.LocalObjectReference {
+	if spec != nil && len(spec.PullSecrets) > 0 {
+		return spec.PullSecrets
+	}
+	if len(PullSecrets) == 0 {
+		return nil
+	}
+	res := make([]corev1.LocalObjectReference, 0, len(PullSecrets))
+	for _, name := range PullSecrets {
+		res = append(res, corev1.LocalObjectReference{Name: name})
+	}
+	return res
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image      string
+		repository string
+		tag        string
+	}{
+		{"aerospike/aerospike-server", "aerospike/aerospike-server", ""},
+		{"aerospike/aerospike-server:4.2.0.3", "aerospike/aerospike-server", "4.2.0.3"},
+		{"registry.example.com:5000/aerospike-server", "registry.example.com:5000/aerospike-server", ""},
+		{"registry.example.com:5000/aerospike-server:4.2.0.3", "registry.example.com:5000/aerospike-server", "4.2.0.3"},
+	}
+	for _, test := range tests {
+		repository, tag := SplitImage(test.image)
+		assert.Equal(t, test.repository, repository)
+		assert.Equal(t, test.tag, tag)
+	}
+}
+
+func TestServerImage(t *testing.T) {
+	tests := []struct {
+		spec  *aerospikev1alpha2.ImageSpec
+		image string
+	}{
+		{nil, "aerospike/aerospike-server:4.2.0.3"},
+		{&aerospikev1alpha2.ImageSpec{}, "aerospike/aerospike-server:4.2.0.3"},
+		{&aerospikev1alpha2.ImageSpec{Server: "registry.example.com/aerospike-server"}, "registry.example.com/aerospike-server:4.2.0.3"},
+		{&aerospikev1alpha2.ImageSpec{Server: "registry.example.com/aerospike-server:4.2.0.3"}, "registry.example.com/aerospike-server:4.2.0.3"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.image, ServerImage(test.spec, "4.2.0.3"))
+	}
+}
+
+func TestGetPullPolicy(t *testing.T) {
+	defer func() { PullPolicy = "" }()
+	assert.Equal(t, corev1.PullAlways, GetPullPolicy(nil, corev1.PullAlways))
+	PullPolicy = string(corev1.PullIfNotPresent)
+	assert.Equal(t, corev1.PullIfNotPresent, GetPullPolicy(nil, corev1.PullAlways))
+	assert.Equal(t, corev1.PullNever, GetPullPolicy(&aerospikev1alpha2.ImageSpec{PullPolicy: corev1.PullNever}, corev1.PullAlways))
+}
+
+func TestGetPullSecrets(t *testing.T) {
+	defer func() { PullSecrets = nil }()
+	assert.Nil(t, GetPullSecrets(nil))
+	PullSecrets = []string{"mirror"}
+	assert.Equal(t, []corev1.LocalObjectReference{{Name: "mirror"}}, GetPullSecrets(nil))
+	spec := &aerospikev1alpha2.ImageSpec{PullSecrets: []corev1.LocalObjectReference{{Name: "other"}}}
+	assert.Equal(t, spec.PullSecrets, GetPullSecrets(spec))
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	listersv1 "k8s.io/client-go/listers/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	aerospikelisters "github.com/travelaudience/aerospike-operator/pkg/client/listers/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/reconciler"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
+)
+
+const (
+	// PhasePending is the phase of AerospikeCluster resources which have not
+	// been reconciled yet.
+	PhasePending = "Pending"
+	// PhaseRunning is the phase of AerospikeCluster resources which match
+	// their spec.
+	PhaseRunning = "Running"
+	// PhaseScaling is the phase of AerospikeCluster resources whose number of
+	// nodes is being changed.
+	PhaseScaling = "Scaling"
+	// PhaseUpgrading is the phase of AerospikeCluster resources which are
+	// being upgraded or rolled back.
+	PhaseUpgrading = "Upgrading"
+	// PhasePaused is the phase of AerospikeCluster resources whose upgrade has
+	// been paused.
+	PhasePaused = "Paused"
+	// PhaseFailed is the phase of AerospikeCluster resources whose upgrade or
+	// rollback has failed.
+	PhaseFailed = "Failed"
+	// PhaseReconciliationPaused is the phase of AerospikeCluster resources
+	// whose reconciliation has been paused.
+	PhaseReconciliationPaused = "ReconciliationPaused"
+
+	// upgradeStatusNone is the value of the status label for
+	// AerospikeCluster resources which are not being upgraded
+	upgradeStatusNone = "none"
+
+	// the label holding the namespace of a resource
+	namespaceLabel = "namespace"
+	// the label holding the name of an AerospikeCluster resource
+	clusterLabel = "cluster"
+	// the label holding the phase of an AerospikeCluster resource
+	phaseLabel = "phase"
+	// the label holding the upgrade status of an AerospikeCluster resource
+	statusLabel = "status"
+)
+
+var (
+	// phases lists every phase an AerospikeCluster resource can be in
+	phases = []string{
+		PhasePending,
+		PhaseRunning,
+		PhaseScaling,
+		PhaseUpgrading,
+		PhasePaused,
+		PhaseFailed,
+		PhaseReconciliationPaused,
+	}
+	// upgradeStatuses lists every upgrade status an AerospikeCluster resource
+	// can be in
+	upgradeStatuses = []string{
+		upgradeStatusNone,
+		reconciler.UpgradeStatusBackupAnnotationValue,
+		reconciler.UpgradeStatusStartedAnnotationValue,
+		reconciler.UpgradeStatusPausedAnnotationValue,
+		reconciler.UpgradeStatusFailedAnnotationValue,
+		reconciler.UpgradeStatusRollbackAnnotationValue,
+		reconciler.UpgradeStatusRollbackFailedAnnotationValue,
+	}
+
+	clusterPhaseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "cluster_phase"),
+		"The phase of each AerospikeCluster resource. The value is 1 for the current phase and 0 otherwise.",
+		[]string{namespaceLabel, clusterLabel, phaseLabel}, nil,
+	)
+	clusterUpgradeStatusDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "cluster_upgrade_status"),
+		"The upgrade status of each AerospikeCluster resource. The value is 1 for the current status and 0 otherwise.",
+		[]string{namespaceLabel, clusterLabel, statusLabel}, nil,
+	)
+	pvcsPendingGCDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "persistentvolumeclaims_pending_gc"),
+		"The number of unmounted persistent volume claims of each AerospikeCluster resource which are waiting to be garbage collected.",
+		[]string{namespaceLabel, clusterLabel}, nil,
+	)
+)
+
+// clusterCollector collects metrics about AerospikeCluster resources and
+// their persistent volume claims from the informer caches.
+type clusterCollector struct {
+	aerospikeClustersLister aerospikelisters.AerospikeClusterLister
+	pvcsLister              listersv1.PersistentVolumeClaimLister
+}
+
+// RegisterClusterCollector registers the collector of the metrics about
+// AerospikeCluster resources and their persistent volume claims.
+func RegisterClusterCollector(aerospikeClustersLister aerospikelisters.AerospikeClusterLister, pvcsLister listersv1.PersistentVolumeClaimLister) error {
+	return Registry.Register(&clusterCollector{
+		aerospikeClustersLister: aerospikeClustersLister,
+		pvcsLister:              pvcsLister,
+	})
+}
+
+// Describe sends the descriptors of the metrics collected by c to ch.
+func (c *clusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clusterPhaseDesc
+	ch <- clusterUpgradeStatusDesc
+	ch <- pvcsPendingGCDesc
+}
+
+// Collect sends the current value of the metrics collected by c to ch.
+func (c *clusterCollector) Collect(ch chan<- prometheus.Metric) {
+	aerospikeClusters, err := c.aerospikeClustersLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("failed to list aerospikeclusters: %v", err)
+		return
+	}
+	for _, aerospikeCluster := range aerospikeClusters {
+		current := clusterPhase(aerospikeCluster)
+		for _, phase := range phases {
+			ch <- prometheus.MustNewConstMetric(clusterPhaseDesc, prometheus.GaugeValue, boolToFloat64(phase == current),
+				aerospikeCluster.Namespace, aerospikeCluster.Name, phase)
+		}
+		current = clusterUpgradeStatus(aerospikeCluster)
+		for _, status := range upgradeStatuses {
+			ch <- prometheus.MustNewConstMetric(clusterUpgradeStatusDesc, prometheus.GaugeValue, boolToFloat64(status == current),
+				aerospikeCluster.Namespace, aerospikeCluster.Name, status)
+		}
+	}
+
+	pvcs, err := c.pvcsLister.List(labels.SelectorFromSet(map[string]string{
+		selectors.LabelAppKey: selectors.LabelAppVal,
+	}))
+	if err != nil {
+		log.Errorf("failed to list persistentvolumeclaims: %v", err)
+		return
+	}
+	type clusterKey struct {
+		namespace string
+		name      string
+	}
+	pending := make(map[clusterKey]int)
+	for _, pvc := range pvcs {
+		// only pvcs that have been unmounted and have a non-zero ttl are
+		// eventually deleted by the garbage collector
+		if _, ok := pvc.Annotations[reconciler.LastUnmountedOnAnnotation]; !ok {
+			continue
+		}
+		ttl, err := astime.ParseDuration(pvc.Annotations[reconciler.PVCTTLAnnotation])
+		if err != nil || ttl == time.Second*0 {
+			continue
+		}
+		pending[clusterKey{pvc.Namespace, pvc.Labels[selectors.LabelClusterKey]}]++
+	}
+	for key, count := range pending {
+		ch <- prometheus.MustNewConstMetric(pvcsPendingGCDesc, prometheus.GaugeValue, float64(count), key.namespace, key.name)
+	}
+}
+
+// clusterPhase returns the phase aerospikeCluster is currently in.
+func clusterPhase(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	switch aerospikeCluster.Annotations[reconciler.UpgradeStatusAnnotationKey] {
+	case reconciler.UpgradeStatusFailedAnnotationValue, reconciler.UpgradeStatusRollbackFailedAnnotationValue:
+		return PhaseFailed
+	}
+	// no other changes are made while reconciliation is paused
+	if aerospikeCluster.Spec.Paused {
+		return PhaseReconciliationPaused
+	}
+	switch aerospikeCluster.Annotations[reconciler.UpgradeStatusAnnotationKey] {
+	case reconciler.UpgradeStatusPausedAnnotationValue:
+		return PhasePaused
+	case reconciler.UpgradeStatusBackupAnnotationValue, reconciler.UpgradeStatusStartedAnnotationValue, reconciler.UpgradeStatusRollbackAnnotationValue:
+		return PhaseUpgrading
+	}
+	if aerospikeCluster.Status.Version == "" {
+		return PhasePending
+	}
+	if aerospikeCluster.Status.Version != aerospikeCluster.Spec.Version {
+		return PhaseUpgrading
+	}
+	if aerospikeCluster.Status.NodeCount != aerospikeCluster.Spec.NodeCount {
+		return PhaseScaling
+	}
+	return PhaseRunning
+}
+
+// clusterUpgradeStatus returns the upgrade status of aerospikeCluster.
+func clusterUpgradeStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	if status, ok := aerospikeCluster.Annotations[reconciler.UpgradeStatusAnnotationKey]; ok && status != "" {
+		return status
+	}
+	return upgradeStatusNone
+}
+
+// boolToFloat64 returns 1 if b is true and 0 otherwise.
+func boolToFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
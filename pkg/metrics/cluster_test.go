@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/reconciler"
+)
+
+func TestClusterPhase(t *testing.T) {
+	tests := []struct {
+		upgradeStatus string
+		specVersion   string
+		statusVersion string
+		specNodes     int32
+		statusNodes   int32
+		phase         string
+		status        string
+		paused        bool
+	}{
+		{"", "4.5.0.5", "", 3, 0, PhasePending, upgradeStatusNone, false},
+		{"", "4.5.0.5", "4.5.0.5", 3, 3, PhaseRunning, upgradeStatusNone, false},
+		{"", "4.5.0.5", "4.5.0.5", 4, 3, PhaseScaling, upgradeStatusNone, false},
+		{"", "4.5.3.2", "4.5.0.5", 3, 3, PhaseUpgrading, upgradeStatusNone, false},
+		{reconciler.UpgradeStatusBackupAnnotationValue, "4.5.3.2", "4.5.0.5", 3, 3, PhaseUpgrading, reconciler.UpgradeStatusBackupAnnotationValue, false},
+		{reconciler.UpgradeStatusStartedAnnotationValue, "4.5.3.2", "4.5.0.5", 3, 3, PhaseUpgrading, reconciler.UpgradeStatusStartedAnnotationValue, false},
+		{reconciler.UpgradeStatusRollbackAnnotationValue, "4.5.0.5", "4.5.3.2", 3, 3, PhaseUpgrading, reconciler.UpgradeStatusRollbackAnnotationValue, false},
+		{reconciler.UpgradeStatusPausedAnnotationValue, "4.5.3.2", "4.5.3.2", 3, 3, PhasePaused, reconciler.UpgradeStatusPausedAnnotationValue, false},
+		{reconciler.UpgradeStatusFailedAnnotationValue, "4.5.3.2", "4.5.0.5", 3, 3, PhaseFailed, reconciler.UpgradeStatusFailedAnnotationValue, false},
+		{reconciler.UpgradeStatusRollbackFailedAnnotationValue, "4.5.0.5", "4.5.3.2", 3, 3, PhaseFailed, reconciler.UpgradeStatusRollbackFailedAnnotationValue, false},
+		{"", "4.5.0.5", "4.5.0.5", 4, 3, PhaseReconciliationPaused, upgradeStatusNone, true},
+		{reconciler.UpgradeStatusStartedAnnotationValue, "4.5.3.2", "4.5.0.5", 3, 3, PhaseReconciliationPaused, reconciler.UpgradeStatusStartedAnnotationValue, true},
+		{reconciler.UpgradeStatusFailedAnnotationValue, "4.5.3.2", "4.5.0.5", 3, 3, PhaseFailed, reconciler.UpgradeStatusFailedAnnotationValue, true},
+	}
+	for _, test := range tests {
+		aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{},
+			},
+			Spec: aerospikev1alpha2.AerospikeClusterSpec{
+				Version:   test.specVersion,
+				NodeCount: test.specNodes,
+				Paused:    test.paused,
+			},
+		}
+		aerospikeCluster.Status.Version = test.statusVersion
+		aerospikeCluster.Status.NodeCount = test.statusNodes
+		if test.upgradeStatus != "" {
+			aerospikeCluster.Annotations[reconciler.UpgradeStatusAnnotationKey] = test.upgradeStatus
+		}
+		assert.Equal(t, test.phase, clusterPhase(aerospikeCluster))
+		assert.Equal(t, test.status, clusterUpgradeStatus(aerospikeCluster))
+	}
+}
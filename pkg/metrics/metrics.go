@@ -0,0 +1,141 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+const (
+	// namespace is the prefix of the names of every metric exposed by
+	// aerospike-operator
+	namespace = "aerospike_operator"
+	// Path is the path at which metrics are exposed.
+	Path = "/metrics"
+
+	// the label holding the name of a controller
+	controllerLabel = "controller"
+	// the label holding the type of a backup/restore operation
+	operationLabel = "operation"
+	// the label holding the outcome of a backup/restore operation
+	resultLabel = "result"
+
+	// ResultSucceeded is the value of the result label for backup/restore
+	// operations that have succeeded.
+	ResultSucceeded = "succeeded"
+	// ResultFailed is the value of the result label for backup/restore
+	// operations that have failed.
+	ResultFailed = "failed"
+)
+
+var (
+	// Registry is the registry holding every metric exposed by
+	// aerospike-operator.
+	Registry = prometheus.NewRegistry()
+
+	reconcileTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_total",
+		Help:      "The number of items processed by each controller.",
+	}, []string{controllerLabel})
+	reconcileErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_errors_total",
+		Help:      "The number of items whose processing has failed in each controller.",
+	}, []string{controllerLabel})
+	reconcileDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "reconcile_duration_seconds",
+		Help:      "The time taken to process an item in each controller.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900, 3600},
+	}, []string{controllerLabel})
+
+	backupRestoreTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "backup_restore_jobs_total",
+		Help:      "The number of backup and restore jobs that have finished, by outcome.",
+	}, []string{operationLabel, resultLabel})
+	backupRestoreDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "backup_restore_job_duration_seconds",
+		Help:      "The time taken by backup and restore jobs to finish.",
+		Buckets:   prometheus.ExponentialBuckets(30, 2, 10),
+	}, []string{operationLabel, resultLabel})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		reconcileTotal,
+		reconcileErrorsTotal,
+		reconcileDurationSeconds,
+		backupRestoreTotal,
+		backupRestoreDurationSeconds,
+	)
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
+
+// ObserveReconcile records that the specified controller has processed an
+// item, taking the specified duration and failing if err is not nil.
+func ObserveReconcile(controller string, duration time.Duration, err error) {
+	reconcileTotal.WithLabelValues(controller).Inc()
+	reconcileDurationSeconds.WithLabelValues(controller).Observe(duration.Seconds())
+	if err != nil {
+		reconcileErrorsTotal.WithLabelValues(controller).Inc()
+	}
+}
+
+// ObserveBackupRestoreJob records that a backup or restore job has finished
+// with the specified result, taking the specified duration.
+func ObserveBackupRestoreJob(operation, result string, duration time.Duration) {
+	backupRestoreTotal.WithLabelValues(operation, result).Inc()
+	backupRestoreDurationSeconds.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// Serve exposes the metrics in Registry over HTTP at the specified address
+// until stopCh is closed.
+func Serve(address string, stopCh <-chan struct{}) {
+	mux := http.NewServeMux()
+	mux.Handle(Path, promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+	srv := http.Server{
+		Addr:    address,
+		Handler: mux,
+	}
+
+	// shutdown the server when stopCh is closed
+	go func() {
+		<-stopCh
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+		log.Debugf("metrics server has been shutdown")
+	}()
+
+	// start listening on the specified address
+	log.Infof("serving metrics at %s%s", address, Path)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Errorf("failed to serve metrics: %v", err)
+	}
+}
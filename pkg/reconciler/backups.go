@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -125,3 +126,114 @@ func GetBackupName(ns, sourceVersion, targetVersion string) string {
 		strings.Replace(targetVersion, ".", "", -1),
 	)
 }
+
+// deleteClusterBackup deletes the pre-upgrade backup of each namespace of
+// aerospikeCluster, so that it can be performed again.
+func (r *AerospikeClusterReconciler) deleteClusterBackup(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		name := GetBackupName(namespace.Name, aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)
+		err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceBackups(aerospikeCluster.Namespace).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !kubeerrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreCluster restores the backup of each namespace of aerospikeCluster
+// created before upgrading to the specified version, unless the corresponding
+// restore has already been created.
+func (r *AerospikeClusterReconciler) restoreCluster(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgradeVersion string) error {
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		name := GetBackupName(namespace.Name, aerospikeCluster.Spec.Version, upgradeVersion)
+		_, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceRestores(aerospikeCluster.Namespace).Get(name, metav1.GetOptions{})
+		if err == nil {
+			continue
+		}
+		if !kubeerrors.IsNotFound(err) {
+			return err
+		}
+		if err := r.createNamespaceRestore(aerospikeCluster, namespace.Name, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) isClusterRestoreFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgradeVersion string) (bool, error) {
+	// if the restore of one of the namespaces have not finished, return false
+	for _, namespace := range aerospikeCluster.Spec.Namespaces {
+		name := GetBackupName(namespace.Name, aerospikeCluster.Spec.Version, upgradeVersion)
+		if finished, err := r.isRestoreCompleted(aerospikeCluster, name); err != nil {
+			return false, err
+		} else if !finished {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (r *AerospikeClusterReconciler) createNamespaceRestore(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ns, name string) error {
+	// the restore must have the same name as the backup, as it is used to
+	// locate the backup in the storage
+	restore := aerospikev1alpha2.AerospikeNamespaceRestore{
+		ObjectMeta: v1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				selectors.LabelAppKey:       selectors.LabelAppVal,
+				selectors.LabelClusterKey:   aerospikeCluster.Name,
+				selectors.LabelNamespaceKey: ns,
+			},
+			Namespace: aerospikeCluster.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         aerospikev1alpha2.SchemeGroupVersion.String(),
+					Kind:               crd.AerospikeClusterKind,
+					Name:               aerospikeCluster.Name,
+					UID:                aerospikeCluster.UID,
+					Controller:         pointers.NewBool(true),
+					BlockOwnerDeletion: pointers.NewBool(true),
+				},
+			},
+		},
+		Spec: aerospikev1alpha2.AerospikeNamespaceRestoreSpec{
+			Target: aerospikev1alpha2.TargetNamespace{
+				Cluster:   aerospikeCluster.Name,
+				Namespace: ns,
+			},
+			Storage: &aerospikev1alpha2.BackupStorageSpec{
+				Type:            aerospikeCluster.Spec.BackupSpec.Storage.Type,
+				Bucket:          aerospikeCluster.Spec.BackupSpec.Storage.Bucket,
+				Secret:          aerospikeCluster.Spec.BackupSpec.Storage.GetSecret(),
+				SecretNamespace: aerospikeCluster.Spec.BackupSpec.Storage.SecretNamespace,
+				SecretKey:       aerospikeCluster.Spec.BackupSpec.Storage.SecretKey,
+			},
+		},
+	}
+
+	_, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceRestores(aerospikeCluster.Namespace).Create(&restore)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) isRestoreCompleted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, name string) (bool, error) {
+	// get the AerospikeNamespaceRestore resource
+	restore, err := r.aerospikeclientset.AerospikeV1alpha2().AerospikeNamespaceRestores(aerospikeCluster.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	// look for ConditionRestoreFinished
+	for _, condition := range restore.Status.Conditions {
+		if condition.Type == common.ConditionRestoreFinished &&
+			condition.Status == apiextensions.ConditionTrue {
+			return true, nil
+		} else if condition.Type == common.ConditionRestoreFailed &&
+			condition.Status == apiextensions.ConditionTrue {
+			return false, errors.ClusterRestoreFailed
+		}
+	}
+	return false, nil
+}
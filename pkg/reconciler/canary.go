@@ -0,0 +1,363 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+const (
+	// the name of the statistic that indicates whether the cluster a node
+	// belongs to is whole
+	clusterIntegrityStat = "cluster_integrity"
+)
+
+var (
+	// the names of the namespace statistics that count the transactions which
+	// have failed with an error
+	nsClientErrorStats = []string{
+		"client_tsvc_error",
+		"client_read_error",
+		"client_write_error",
+		"client_delete_error",
+		"client_udf_error",
+	}
+)
+
+// canaryCheckFailure describes a failed health check of the canary pods.
+type canaryCheckFailure struct {
+	reason  string
+	message string
+}
+
+// maybeSoakCanaryPods holds the upgrade of the pod with the specified index
+// for the soak period if it is the first pod to be upgraded after the canary
+// pods, checking the health of the canary pods in the meantime. The time at
+// which the soak period started is recorded in an annotation, and an
+// errors.RequeueAfter error is returned until the soak period has elapsed so
+// that no worker is blocked. If any health check fails, the upgrade is paused
+// and errors.UpgradePaused is returned.
+func (r *AerospikeClusterReconciler) maybeSoakCanaryPods(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, pod *corev1.Pod, upgrade *versioning.VersionUpgrade) error {
+	if aerospikeCluster.Spec.CanaryUpgrade == nil {
+		return nil
+	}
+	podCount, soakPeriod, maxClientErrors, err := getCanaryUpgradeSpec(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	// pods are upgraded in ascending order of their index, so the canary pods
+	// are the ones with an index smaller than podCount
+	if index != podCount {
+		return nil
+	}
+	// skip the soak period if the pod has already been upgraded, as the canary
+	// pods have already passed the health checks
+	version, err := getAerospikeServerVersionFromPod(pod)
+	if err != nil {
+		return err
+	}
+	if version == aerospikeCluster.Spec.Version {
+		return nil
+	}
+
+	canaries := make([]*corev1.Pod, 0, podCount)
+	for i := 0; i < podCount; i++ {
+		canary, err := r.getPodWithIndex(aerospikeCluster, i)
+		if err != nil {
+			return err
+		}
+		if canary == nil {
+			return fmt.Errorf("canary pod with index %d not found", i)
+		}
+		canaries = append(canaries, canary)
+	}
+
+	startedOn, baseline, soaking := getCanarySoakState(aerospikeCluster)
+	if !soaking {
+		// grab the number of client errors reported before the soak period
+		baseline, err := countClientErrors(aerospikeCluster, canaries)
+		if err != nil {
+			return err
+		}
+		if err := r.signalCanarySoakStarted(aerospikeCluster, podCount, soakPeriod, baseline); err != nil {
+			return err
+		}
+		return &errors.RequeueAfter{Delay: minDuration(canaryHealthCheckInterval, soakPeriod)}
+	}
+
+	// check the health of the canary pods until the soak period elapses
+	if failure := checkCanaryHealth(aerospikeCluster, canaries, baseline, maxClientErrors); failure != nil {
+		return r.signalUpgradePaused(aerospikeCluster, upgrade, failure)
+	}
+	if remaining := soakPeriod - time.Since(startedOn); remaining > 0 {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debugf("canary pod(s) are healthy, %s of the soak period remaining", remaining.Round(time.Second))
+		return &errors.RequeueAfter{Delay: minDuration(canaryHealthCheckInterval, remaining)}
+	}
+	// migrations triggered by the upgrade of the canary pods must have
+	// finished by the end of the soak period
+	for _, canary := range canaries {
+		migrations, err := PodHasMigrationsInProgress(canary)
+		if err != nil || migrations {
+			return r.signalUpgradePaused(aerospikeCluster, upgrade, &canaryCheckFailure{
+				reason:  events.ReasonCanaryMigrationsCheckFailed,
+				message: fmt.Sprintf("pod %s has migrations in progress after the soak period", meta.Key(canary)),
+			})
+		}
+	}
+
+	return r.signalCanarySoakFinished(aerospikeCluster)
+}
+
+// getCanarySoakState returns the time at which the soak period of the canary
+// pods of aerospikeCluster started and the number of client errors they
+// reported at that time, as well as whether the soak period has started.
+func getCanarySoakState(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (time.Time, int64, bool) {
+	startedOn, err := time.Parse(time.RFC3339, aerospikeCluster.Annotations[canarySoakStartedOnAnnotation])
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	baseline, err := strconv.ParseInt(aerospikeCluster.Annotations[canarySoakClientErrorsAnnotation], 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return startedOn, baseline, true
+}
+
+// clearCanarySoakState removes the annotations holding the state of the soak
+// period of the canary pods from aerospikeCluster.
+func clearCanarySoakState(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) {
+	removeAerospikeClusterAnnotation(aerospikeCluster, canarySoakStartedOnAnnotation)
+	removeAerospikeClusterAnnotation(aerospikeCluster, canarySoakClientErrorsAnnotation)
+}
+
+func (r *AerospikeClusterReconciler) signalCanarySoakStarted(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, podCount int, soakPeriod time.Duration, baseline int64) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setAerospikeClusterAnnotation(aerospikeCluster, canarySoakStartedOnAnnotation, time.Now().Format(time.RFC3339))
+	setAerospikeClusterAnnotation(aerospikeCluster, canarySoakClientErrorsAnnotation, strconv.FormatInt(baseline, 10))
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonCanarySoakStarted,
+		"checking the health of %d canary pod(s) for %s", podCount, soakPeriod)
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Infof("checking the health of %d canary pod(s) for %s", podCount, soakPeriod)
+
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) signalCanarySoakFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	clearCanarySoakState(aerospikeCluster)
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonCanarySoakFinished,
+		"canary pod(s) passed every health check, upgrading the remaining pods")
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Info("canary pod(s) passed every health check, upgrading the remaining pods")
+
+	return nil
+}
+
+// minDuration returns the shortest of the specified durations.
+func minDuration(a, b time.Duration) time.Duration {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// checkCanaryHealth checks that every canary pod runs the target version and
+// belongs to a whole cluster, and that the canary pods have not reported more
+// than maxClientErrors client errors since baseline was taken. It returns nil
+// if every check passes.
+func checkCanaryHealth(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, canaries []*corev1.Pod, baseline, maxClientErrors int64) *canaryCheckFailure {
+	for _, canary := range canaries {
+		version, err := getAerospikeServerVersionFromPod(canary)
+		if err != nil || version != aerospikeCluster.Spec.Version {
+			return &canaryCheckFailure{
+				reason:  events.ReasonCanaryVersionCheckFailed,
+				message: fmt.Sprintf("pod %s does not report version %s", meta.Key(canary), aerospikeCluster.Spec.Version),
+			}
+		}
+		integrity, err := getClusterIntegrity(canary)
+		if err != nil || !integrity {
+			return &canaryCheckFailure{
+				reason:  events.ReasonCanaryIntegrityCheckFailed,
+				message: fmt.Sprintf("pod %s does not report cluster integrity", meta.Key(canary)),
+			}
+		}
+	}
+	errs, err := countClientErrors(aerospikeCluster, canaries)
+	if err != nil {
+		return &canaryCheckFailure{
+			reason:  events.ReasonCanaryClientErrorsCheckFailed,
+			message: fmt.Sprintf("failed to read client error statistics: %v", err),
+		}
+	}
+	if errs-baseline > maxClientErrors {
+		return &canaryCheckFailure{
+			reason:  events.ReasonCanaryClientErrorsCheckFailed,
+			message: fmt.Sprintf("canary pod(s) reported %d client error(s), more than the allowed %d", errs-baseline, maxClientErrors),
+		}
+	}
+	return nil
+}
+
+// getClusterIntegrity returns whether the aerospike node running on pod
+// reports that the cluster it belongs to is whole.
+func getClusterIntegrity(pod *corev1.Pod) (bool, error) {
+	res, err := runInfoCommandOnPod(pod, "statistics")
+	if err != nil {
+		return false, err
+	}
+	integrity, ok := asutils.ParseStatistics(res["statistics"])[clusterIntegrityStat]
+	if !ok {
+		return false, fmt.Errorf("%s is not present", clusterIntegrityStat)
+	}
+	return strconv.ParseBool(integrity)
+}
+
+// countClientErrors returns the total number of client errors reported by the
+// aerospike nodes running on the specified pods across every namespace.
+func countClientErrors(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pods []*corev1.Pod) (int64, error) {
+	var res int64
+	for _, pod := range pods {
+		for _, ns := range aerospikeCluster.Spec.Namespaces {
+			stats, err := getNamespaceStatistics(pod, ns.Name)
+			if err != nil {
+				return 0, err
+			}
+			for _, stat := range nsClientErrorStats {
+				// statistics which are not reported by the current version
+				// of aerospike are ignored
+				v, ok := stats[stat]
+				if !ok {
+					continue
+				}
+				n, err := strconv.ParseInt(v, 10, 64)
+				if err != nil {
+					return 0, err
+				}
+				res += n
+			}
+		}
+	}
+	return res, nil
+}
+
+// signalUpgradePaused sets the UpgradePaused condition with the reason of the
+// specified failure in aerospikeCluster, and marks its upgrade as paused so
+// that it is not resumed until a recovery action is specified. It returns
+// errors.UpgradePaused unless the cluster cannot be patched.
+func (r *AerospikeClusterReconciler) signalUpgradePaused(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, upgrade *versioning.VersionUpgrade, failure *canaryCheckFailure) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionUpgradePaused,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             failure.reason,
+		Message:            fmt.Sprintf("upgrade from version %s to %s paused: %s", upgrade.Source, upgrade.Target, failure.message),
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusPausedAnnotationValue)
+	// the health checks are performed again if the upgrade is resumed
+	clearCanarySoakState(aerospikeCluster)
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, failure.reason,
+		"upgrade from version %s to %s paused: %s", upgrade.Source, upgrade.Target, failure.message)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Warnf("upgrade from version %s to %s paused: %s", upgrade.Source, upgrade.Target, failure.message)
+
+	return errors.UpgradePaused
+}
+
+// getCanaryUpgradeSpec returns the number of canary pods, the soak period and
+// the maximum number of client errors requested for the specified cluster, or
+// the defaults.
+func getCanaryUpgradeSpec(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (int, time.Duration, int64, error) {
+	podCount, soakPeriod, maxClientErrors := defaultCanaryPodCount, defaultCanarySoakPeriod, int64(0)
+	spec := aerospikeCluster.Spec.CanaryUpgrade
+	if spec.PodCount != nil {
+		podCount = int(*spec.PodCount)
+	}
+	if spec.SoakPeriod != nil {
+		soakPeriod = *spec.SoakPeriod
+	}
+	if spec.MaxClientErrors != nil {
+		maxClientErrors = *spec.MaxClientErrors
+	}
+	soak, err := astime.ParseDuration(soakPeriod)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return podCount, soak, maxClientErrors, nil
+}
+
+// clearUpgradePaused sets the UpgradePaused condition of aerospikeCluster to
+// false with the specified reason if its upgrade has been paused.
+func clearUpgradePaused(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, reason string) {
+	if c := getCondition(aerospikeCluster, common.ConditionUpgradePaused); c == nil || c.Status != apiextensions.ConditionTrue {
+		return
+	}
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionUpgradePaused,
+		Status:             apiextensions.ConditionFalse,
+		Reason:             reason,
+		Message:            "upgrade is not paused",
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+}
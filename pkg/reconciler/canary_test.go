@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func TestGetCanarySoakState(t *testing.T) {
+	startedOn := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name             string
+		annotations      map[string]string
+		expectedSoaking  bool
+		expectedBaseline int64
+	}{
+		{"soak period not started", nil, false, 0},
+		{"soak period started", map[string]string{
+			canarySoakStartedOnAnnotation:    startedOn.Format(time.RFC3339),
+			canarySoakClientErrorsAnnotation: "42",
+		}, true, 42},
+		{"invalid start time", map[string]string{
+			canarySoakStartedOnAnnotation:    "yesterday",
+			canarySoakClientErrorsAnnotation: "42",
+		}, false, 0},
+		{"missing client errors", map[string]string{
+			canarySoakStartedOnAnnotation: startedOn.Format(time.RFC3339),
+		}, false, 0},
+	}
+	for _, test := range tests {
+		aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: test.annotations,
+			},
+		}
+		actualStartedOn, baseline, soaking := getCanarySoakState(aerospikeCluster)
+		assert.Equal(t, test.expectedSoaking, soaking, test.name)
+		assert.Equal(t, test.expectedBaseline, baseline, test.name)
+		if soaking {
+			assert.True(t, startedOn.Equal(actualStartedOn), test.name)
+		}
+	}
+}
+
+func TestClearCanarySoakState(t *testing.T) {
+	aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				canarySoakStartedOnAnnotation:    time.Now().Format(time.RFC3339),
+				canarySoakClientErrorsAnnotation: "0",
+			},
+		},
+	}
+	clearCanarySoakState(aerospikeCluster)
+	_, _, soaking := getCanarySoakState(aerospikeCluster)
+	assert.False(t, soaking)
+}
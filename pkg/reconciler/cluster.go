@@ -18,6 +18,7 @@ package reconciler
 
 import (
 	log "github.com/sirupsen/logrus"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	listersv1 "k8s.io/client-go/listers/core/v1"
 	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
@@ -35,49 +36,120 @@ import (
 type AerospikeClusterReconciler struct {
 	kubeclientset          kubernetes.Interface
 	aerospikeclientset     aerospikeclientset.Interface
+	dynamicclientset       dynamic.Interface
 	podsLister             listersv1.PodLister
 	configMapsLister       listersv1.ConfigMapLister
 	servicesLister         listersv1.ServiceLister
 	pvcsLister             listersv1.PersistentVolumeClaimLister
+	pvsLister              listersv1.PersistentVolumeLister
+	nodesLister            listersv1.NodeLister
 	scsLister              storagelistersv1.StorageClassLister
 	aerospikeBackupsLister aerospikelisters.AerospikeNamespaceBackupLister
 	recorder               record.EventRecorder
+	// the resources of the prometheus operator served by the api server
+	monitoringResources monitoringResourcesCache
 }
 
 func New(kubeclientset kubernetes.Interface,
 	aerospikeclientset aerospikeclientset.Interface,
+	dynamicclientset dynamic.Interface,
 	podsLister listersv1.PodLister,
 	configMapsLister listersv1.ConfigMapLister,
 	servicesLister listersv1.ServiceLister,
 	pvcsLister listersv1.PersistentVolumeClaimLister,
+	pvsLister listersv1.PersistentVolumeLister,
+	nodesLister listersv1.NodeLister,
 	scsLister storagelistersv1.StorageClassLister,
 	aerospikeBackupsLister aerospikelisters.AerospikeNamespaceBackupLister,
 	recorder record.EventRecorder) *AerospikeClusterReconciler {
 	return &AerospikeClusterReconciler{
 		kubeclientset:          kubeclientset,
 		aerospikeclientset:     aerospikeclientset,
+		dynamicclientset:       dynamicclientset,
 		podsLister:             podsLister,
 		configMapsLister:       configMapsLister,
 		servicesLister:         servicesLister,
 		pvcsLister:             pvcsLister,
+		pvsLister:              pvsLister,
+		nodesLister:            nodesLister,
 		scsLister:              scsLister,
 		aerospikeBackupsLister: aerospikeBackupsLister,
 		recorder:               recorder,
 	}
 }
 
-// MaybeReconcile checks if reconciliation is needed.
+// MaybeReconcile checks if reconciliation is needed, and updates the
+// conditions of aerospikeCluster with the outcome.
 func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	err := r.reconcile(aerospikeCluster)
+
+	// a request to reconcile the cluster again after a delay is not a failure
+	reconcileErr := err
+	if _, ok := errors.IsRequeueAfter(err); ok {
+		reconcileErr = nil
+	}
+
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+	r.updateClusterConditions(aerospikeCluster, reconcileErr)
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Errorf("failed to update conditions: %v", err)
+	}
+	return err
+}
+
+// reconcile performs the changes required for the aerospikecluster and its
+// dependent resources to match its spec.
+func (r *AerospikeClusterReconciler) reconcile(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
 	}).Info("processing cluster")
 
-	// check if a previous upgrade operation has failed, in which case we return
+	// signal that reconciliation has been resumed if it was previously paused
+	if err := r.signalReconciliationResumed(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// keep the cluster at its current version if the upgrade to the requested
+	// version has been rolled back
+	retrying, err := r.holdRolledBackVersion(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+
+	// plan the upgrade to the requested version, if any, and restrict the
+	// current reconcile operation to the current hop of the plan
+	if err := r.planUpgrade(aerospikeCluster); err != nil {
+		return err
+	}
+
+	// retry the rolled back upgrade if requested
+	if retrying {
+		return r.retryUpgrade(aerospikeCluster)
+	}
+
+	// check if a previous upgrade operation has failed or has been paused, in
+	// which case we perform the requested recovery action (if any) and return
 	if v, ok := aerospikeCluster.ObjectMeta.Annotations[UpgradeStatusAnnotationKey]; ok {
-		if v == UpgradeStatusFailedAnnotationValue {
+		if v == UpgradeStatusFailedAnnotationValue || v == UpgradeStatusPausedAnnotationValue {
+			recovering, err := r.maybeRecoverFromFailedUpgrade(aerospikeCluster)
+			if err != nil {
+				return err
+			}
+			if !recovering {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+				}).Warnf("a previous version upgrade has %s. aborting", v)
+			}
+			return nil
+		}
+		if v == UpgradeStatusRollbackFailedAnnotationValue {
 			log.WithFields(log.Fields{
 				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-			}).Warn("a previous version upgrade has failed. aborting")
+			}).Warn("the rollback of a failed version upgrade has failed. aborting")
 			return nil
 		}
 	}
@@ -167,6 +239,18 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 	if err := r.ensureNetworkPolicy(aerospikeCluster); err != nil {
 		return err
 	}
+	// create/update the pod disruption budget
+	if err := r.ensurePodDisruptionBudget(aerospikeCluster); err != nil {
+		return err
+	}
+	// create/update/delete the servicemonitor and prometheusrule
+	if err := r.ensureMonitoringResources(aerospikeCluster); err != nil {
+		return err
+	}
+	// expand the persistent volume claims that can be expanded in place
+	if err := r.expandPersistentVolumeClaims(aerospikeCluster); err != nil {
+		return err
+	}
 
 	oldCluster := aerospikeCluster.DeepCopy()
 	// make sure that pods are up-to-date with the spec
@@ -178,12 +262,20 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 				log.Errorf("failed to signal failed upgrade: %v", err)
 			}
 		}
+		// a paused upgrade has already been signaled and is not retried
+		if err == errors.UpgradePaused {
+			return nil
+		}
 		// return the original error
 		return err
 	}
 
 	// update the status field of aerospikeCluster
 	r.updateStatus(aerospikeCluster)
+	// report the state of cross-datacenter replication
+	r.updateXDRStatus(aerospikeCluster)
+	// report the observed state of the aerospike nodes
+	r.updateObservedStatus(aerospikeCluster)
 
 	// patch the cluster with the changes performed in the ensurePods and
 	// updateStatus
@@ -191,6 +283,11 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 		return err
 	}
 
+	// signal that persistent volume claims match the storage spec
+	if err := r.signalStorageUpdateFinished(aerospikeCluster); err != nil {
+		return err
+	}
+
 	// set the appropriate annotations and conditions if performing an upgrade
 	if upgrade != nil {
 		if _, err := r.signalUpgradeFinished(aerospikeCluster, upgrade); err != nil {
@@ -198,5 +295,12 @@ func (r *AerospikeClusterReconciler) MaybeReconcile(aerospikeCluster *aerospikev
 		}
 	}
 
+	// restore the pre-upgrade backup if rolling back a failed upgrade
+	if aerospikeCluster.Annotations[UpgradeStatusAnnotationKey] == UpgradeStatusRollbackAnnotationValue {
+		if err := r.maybeFinishUpgradeRollback(aerospikeCluster); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+)
+
+const (
+	// the info commands used to observe the state of an aerospike node
+	nodeInfoCommand       = "node"
+	buildInfoCommand      = "build"
+	statisticsInfoCommand = "statistics"
+	// namespaceInfoCommandPrefix is the prefix of the info command used to
+	// get the statistics of an aerospike namespace
+	namespaceInfoCommandPrefix = "namespace/"
+
+	// the names of the node statistics used to build the status of the
+	// aerospikecluster
+	clusterKeyStat                 = "cluster_key"
+	clusterSizeStat                = "cluster_size"
+	migratePartitionsRemainingStat = "migrate_partitions_remaining"
+	// the names of the namespace statistics used to build the status of the
+	// aerospikecluster
+	masterObjectsStat    = "master_objects"
+	memoryUsedBytesStat  = "memory_used_bytes"
+	memorySizeStat       = "memory-size"
+	deviceUsedBytesStat  = "device_used_bytes"
+	deviceTotalBytesStat = "device_total_bytes"
+)
+
+// nodeInfo holds the information reported by the aerospike node running on a
+// given pod.
+type nodeInfo struct {
+	// the pod running the aerospike node
+	pod *corev1.Pod
+	// the id of the aerospike node
+	nodeID string
+	// the build reported by the aerospike node
+	build string
+	// the statistics reported by the aerospike node
+	statistics map[string]string
+	// the statistics reported by the aerospike node for each aerospike
+	// namespace
+	namespaces map[string]map[string]string
+}
+
+// updateObservedStatus updates the status of aerospikeCluster with the number
+// of ready pods and, at most every observedStatusRefreshInterval, with the
+// state of each aerospike node and the usage of each aerospike namespace.
+// Failures to gather information are logged but not propagated, as they must
+// not prevent reconciliation.
+func (r *AerospikeClusterReconciler) updateObservedStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) {
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Warnf("failed to list pods: %v", err)
+		return
+	}
+
+	// the number of ready pods is cheap to compute and is always updated
+	readyNodeCount := int32(0)
+	for _, pod := range pods {
+		if IsPodRunningAndReady(pod) {
+			readyNodeCount++
+		}
+	}
+	aerospikeCluster.Status.ReadyNodeCount = readyNodeCount
+
+	// gathering information from every aerospike node is expensive, and
+	// updating the status causes the aerospikecluster to be reconciled again,
+	// so we do it only if enough time has passed since the last time
+	if t := aerospikeCluster.Status.LastObservedTime; t != nil && time.Since(t.Time) < observedStatusRefreshInterval {
+		return
+	}
+
+	infos := make([]nodeInfo, 0, len(pods))
+	for _, pod := range pods {
+		info := nodeInfo{pod: pod}
+		if IsPodRunningAndReady(pod) {
+			if info, err = getNodeInfo(pod, aerospikeCluster.Spec.Namespaces); err != nil {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.Pod:              meta.Key(pod),
+				}).Warnf("failed to get node information: %v", err)
+				info = nodeInfo{pod: pod}
+			}
+		}
+		infos = append(infos, info)
+	}
+	setObservedStatus(aerospikeCluster, infos)
+	now := metav1.Now()
+	aerospikeCluster.Status.LastObservedTime = &now
+}
+
+// getNodeInfo returns the information reported by the aerospike node running
+// on pod about itself and about the specified aerospike namespaces.
+func getNodeInfo(pod *corev1.Pod, namespaces []aerospikev1alpha2.AerospikeNamespaceSpec) (nodeInfo, error) {
+	commands := []string{nodeInfoCommand, buildInfoCommand, statisticsInfoCommand}
+	for _, ns := range namespaces {
+		commands = append(commands, namespaceInfoCommandPrefix+ns.Name)
+	}
+	res, err := runInfoCommandOnPod(pod, commands...)
+	if err != nil {
+		return nodeInfo{}, err
+	}
+	stats, ok := res[statisticsInfoCommand]
+	if !ok {
+		return nodeInfo{}, fmt.Errorf("failed to get statistics from pod %s", meta.Key(pod))
+	}
+
+	info := nodeInfo{
+		pod:        pod,
+		nodeID:     res[nodeInfoCommand],
+		build:      res[buildInfoCommand],
+		statistics: asutils.ParseStatistics(stats),
+		namespaces: make(map[string]map[string]string, len(namespaces)),
+	}
+	for _, ns := range namespaces {
+		if stats, ok := res[namespaceInfoCommandPrefix+ns.Name]; ok {
+			info.namespaces[ns.Name] = asutils.ParseStatistics(stats)
+		}
+	}
+	return info, nil
+}
+
+// setObservedStatus sets the state of each aerospike node, the cluster key,
+// the cluster integrity and the usage of each aerospike namespace in the
+// status of aerospikeCluster based on the information reported by the
+// aerospike nodes. Aerospike nodes which have not reported any information are
+// considered to break cluster integrity.
+func setObservedStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, infos []nodeInfo) {
+	nodes := make([]aerospikev1alpha2.NodeStatus, 0, len(infos))
+	namespaces := make([]aerospikev1alpha2.NamespaceStatistics, 0, len(aerospikeCluster.Spec.Namespaces))
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		namespaces = append(namespaces, aerospikev1alpha2.NamespaceStatistics{Name: ns.Name})
+	}
+
+	clusterKey := ""
+	// the cluster has integrity only if it has the desired number of nodes
+	integrity := len(infos) > 0 && len(infos) == int(aerospikeCluster.Spec.NodeCount)
+	for i, info := range infos {
+		node := aerospikev1alpha2.NodeStatus{
+			PodName: info.pod.Name,
+			IP:      info.pod.Status.PodIP,
+			NodeID:  info.nodeID,
+			Build:   info.build,
+			Ready:   IsPodRunningAndReady(info.pod),
+		}
+		// fallback to the node id recorded when the pod was created
+		if node.NodeID == "" {
+			node.NodeID = info.pod.Annotations[nodeIdAnnotation]
+		}
+		if info.statistics != nil {
+			node.MigratePartitionsRemaining = parseInt64(info.statistics[migratePartitionsRemainingStat])
+		}
+		nodes = append(nodes, node)
+
+		// every node must report integrity, agree on the cluster key and see
+		// every other node
+		key := info.statistics[clusterKeyStat]
+		if i == 0 {
+			clusterKey = key
+		}
+		if key == "" || key != clusterKey {
+			clusterKey = ""
+			integrity = false
+		}
+		if info.statistics[clusterIntegrityStat] != "true" ||
+			parseInt64(info.statistics[clusterSizeStat]) != int64(len(infos)) {
+			integrity = false
+		}
+
+		for j := range namespaces {
+			stats, ok := info.namespaces[namespaces[j].Name]
+			if !ok {
+				continue
+			}
+			namespaces[j].Objects += parseInt64(stats[masterObjectsStat])
+			namespaces[j].MemoryUsedBytes += parseInt64(stats[memoryUsedBytesStat])
+			namespaces[j].MemoryTotalBytes += parseInt64(stats[memorySizeStat])
+			namespaces[j].DiskUsedBytes += parseInt64(stats[deviceUsedBytesStat])
+			namespaces[j].DiskTotalBytes += parseInt64(stats[deviceTotalBytesStat])
+		}
+	}
+
+	aerospikeCluster.Status.Nodes = nodes
+	aerospikeCluster.Status.ClusterKey = clusterKey
+	aerospikeCluster.Status.ClusterIntegrity = integrity
+	aerospikeCluster.Status.NamespaceStatistics = namespaces
+}
+
+// parseInt64 parses the value of a statistic reported by aerospike, returning
+// zero if the statistic is absent or invalid.
+func parseInt64(s string) int64 {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func newTestPod(name, ip, nodeID string, ready bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{nodeIdAnnotation: nodeID},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: ip,
+		},
+	}
+	if ready {
+		pod.Status.Conditions = []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		}
+	}
+	return pod
+}
+
+func newTestNodeInfo(pod *corev1.Pod, clusterKey string, objects string) nodeInfo {
+	return nodeInfo{
+		pod:    pod,
+		nodeID: pod.Annotations[nodeIdAnnotation],
+		build:  "4.5.3.2",
+		statistics: map[string]string{
+			clusterKeyStat:                 clusterKey,
+			clusterIntegrityStat:           "true",
+			clusterSizeStat:                "2",
+			migratePartitionsRemainingStat: "10",
+		},
+		namespaces: map[string]map[string]string{
+			"as-namespace-0": {
+				masterObjectsStat:    objects,
+				memoryUsedBytesStat:  "100",
+				memorySizeStat:       "1000",
+				deviceUsedBytesStat:  "200",
+				deviceTotalBytesStat: "2000",
+			},
+		},
+	}
+}
+
+func TestSetObservedStatus(t *testing.T) {
+	pod0 := newTestPod("as-cluster-0-0", "10.0.0.1", "a1", true)
+	pod1 := newTestPod("as-cluster-0-1", "10.0.0.2", "a2", true)
+
+	tests := []struct {
+		name              string
+		infos             []nodeInfo
+		expectedKey       string
+		expectedIntegrity bool
+		expectedObjects   int64
+	}{
+		{
+			name:              "healthy",
+			infos:             []nodeInfo{newTestNodeInfo(pod0, "ABC", "5"), newTestNodeInfo(pod1, "ABC", "7")},
+			expectedKey:       "ABC",
+			expectedIntegrity: true,
+			expectedObjects:   12,
+		},
+		{
+			name:              "split brain",
+			infos:             []nodeInfo{newTestNodeInfo(pod0, "ABC", "5"), newTestNodeInfo(pod1, "DEF", "7")},
+			expectedKey:       "",
+			expectedIntegrity: false,
+			expectedObjects:   12,
+		},
+		{
+			name:              "unreachable node",
+			infos:             []nodeInfo{newTestNodeInfo(pod0, "ABC", "5"), {pod: pod1}},
+			expectedKey:       "",
+			expectedIntegrity: false,
+			expectedObjects:   5,
+		},
+		{
+			name:              "missing node",
+			infos:             []nodeInfo{newTestNodeInfo(pod0, "ABC", "5")},
+			expectedKey:       "ABC",
+			expectedIntegrity: false,
+			expectedObjects:   5,
+		},
+	}
+	for _, test := range tests {
+		aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+		setObservedStatus(aerospikeCluster, test.infos)
+
+		assert.Equal(t, test.expectedKey, aerospikeCluster.Status.ClusterKey, test.name)
+		assert.Equal(t, test.expectedIntegrity, aerospikeCluster.Status.ClusterIntegrity, test.name)
+		assert.Len(t, aerospikeCluster.Status.Nodes, len(test.infos), test.name)
+		assert.Equal(t, []aerospikev1alpha2.NamespaceStatistics{
+			{
+				Name:             "as-namespace-0",
+				Objects:          test.expectedObjects,
+				MemoryUsedBytes:  100 * int64(countReporting(test.infos)),
+				MemoryTotalBytes: 1000 * int64(countReporting(test.infos)),
+				DiskUsedBytes:    200 * int64(countReporting(test.infos)),
+				DiskTotalBytes:   2000 * int64(countReporting(test.infos)),
+			},
+		}, aerospikeCluster.Status.NamespaceStatistics, test.name)
+	}
+}
+
+func TestSetObservedStatusNodes(t *testing.T) {
+	pod0 := newTestPod("as-cluster-0-0", "10.0.0.1", "a1", true)
+	pod1 := newTestPod("as-cluster-0-1", "10.0.0.2", "a2", false)
+
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	setObservedStatus(aerospikeCluster, []nodeInfo{newTestNodeInfo(pod0, "ABC", "5"), {pod: pod1}})
+	assert.Equal(t, []aerospikev1alpha2.NodeStatus{
+		{
+			PodName:                    "as-cluster-0-0",
+			IP:                         "10.0.0.1",
+			NodeID:                     "a1",
+			Build:                      "4.5.3.2",
+			Ready:                      true,
+			MigratePartitionsRemaining: 10,
+		},
+		{
+			PodName: "as-cluster-0-1",
+			IP:      "10.0.0.2",
+			NodeID:  "a2",
+			Ready:   false,
+		},
+	}, aerospikeCluster.Status.Nodes)
+}
+
+// countReporting returns the number of nodes which have reported statistics.
+func countReporting(infos []nodeInfo) int {
+	res := 0
+	for _, info := range infos {
+		if info.statistics != nil {
+			res++
+		}
+	}
+	return res
+}
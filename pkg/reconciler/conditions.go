@@ -0,0 +1,223 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+)
+
+// updateClusterConditions updates the Ready, Progressing, Degraded,
+// ScalingInProgress and ConfigApplying conditions of aerospikeCluster based on
+// the current state of its pods and on the outcome of the last reconcile
+// operation (reconcileErr). Failures to list pods are logged but not
+// propagated, as they must not prevent reconciliation.
+func (r *AerospikeClusterReconciler) updateClusterConditions(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, reconcileErr error) {
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Warnf("failed to list pods: %v", err)
+		return
+	}
+	podSpecHash, err := computePodSpecHash(aerospikeCluster)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Warnf("failed to compute the pod spec hash: %v", err)
+		return
+	}
+	configMapHash := buildConfigMap(aerospikeCluster).Annotations[configMapHashAnnotation]
+
+	// count the pods which must be restarted in order to apply the current
+	// configuration or pod customizations
+	outdatedPods := 0
+	for _, pod := range pods {
+		if pod.Annotations[configMapHashAnnotation] != configMapHash || pod.Annotations[podSpecHashAnnotation] != podSpecHash {
+			outdatedPods++
+		}
+	}
+	setClusterConditions(aerospikeCluster, pods, outdatedPods, reconcileErr)
+}
+
+// setClusterConditions sets the Ready, Progressing, Degraded,
+// ScalingInProgress and ConfigApplying conditions of aerospikeCluster based on
+// its pods, on the number of those which are running an outdated
+// configuration and on the outcome of the last reconcile operation.
+func setClusterConditions(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pods []*corev1.Pod, outdatedPods int, reconcileErr error) {
+	desiredNodeCount := aerospikeCluster.Spec.NodeCount
+	currentNodeCount := aerospikeCluster.Status.NodeCount
+	readyNodeCount := int32(0)
+	for _, pod := range pods {
+		if IsPodRunningAndReady(pod) {
+			readyNodeCount++
+		}
+	}
+	upgradeStatus := aerospikeCluster.Annotations[UpgradeStatusAnnotationKey]
+	rolledBackVersion := aerospikeCluster.Annotations[UpgradeRolledBackVersionAnnotationKey]
+	targetVersion := getRequestedVersion(aerospikeCluster)
+	plan := aerospikeCluster.Status.UpgradePlan
+	upgrading := upgradeStatus == UpgradeStatusBackupAnnotationValue ||
+		upgradeStatus == UpgradeStatusStartedAnnotationValue ||
+		upgradeStatus == UpgradeStatusRollbackAnnotationValue ||
+		(plan != nil && int(plan.CurrentHop) < len(plan.Versions)-1) ||
+		(aerospikeCluster.Status.Version != "" && aerospikeCluster.Status.Version != targetVersion)
+	scaleDownRejected := isConditionTrue(aerospikeCluster, common.ConditionScaleDownRejected)
+	scaling := currentNodeCount != desiredNodeCount && !scaleDownRejected
+	storageUpdating := isConditionTrue(aerospikeCluster, common.ConditionStorageUpdateInProgress)
+	// the cluster integrity is only known once the aerospike nodes have been
+	// queried
+	integrityLost := aerospikeCluster.Status.LastObservedTime != nil && !aerospikeCluster.Status.ClusterIntegrity
+	nodesNotReady := readyNodeCount < desiredNodeCount || int32(len(pods)) < desiredNodeCount
+	readyMessage := fmt.Sprintf("%d of %d nodes are ready", readyNodeCount, desiredNodeCount)
+
+	var scalingCondition aerospikev1alpha2.AerospikeClusterCondition
+	switch {
+	case scaleDownRejected:
+		scalingCondition = newClusterCondition(aerospikeCluster, common.ConditionScalingInProgress, apiextensions.ConditionFalse,
+			common.ReasonScaleDownRejected, fmt.Sprintf("scaling down to %d nodes has been rejected", desiredNodeCount))
+	case currentNodeCount < desiredNodeCount:
+		scalingCondition = newClusterCondition(aerospikeCluster, common.ConditionScalingInProgress, apiextensions.ConditionTrue,
+			common.ReasonScalingUp, fmt.Sprintf("scaling up from %d to %d nodes", currentNodeCount, desiredNodeCount))
+	case currentNodeCount > desiredNodeCount:
+		scalingCondition = newClusterCondition(aerospikeCluster, common.ConditionScalingInProgress, apiextensions.ConditionTrue,
+			common.ReasonScalingDown, fmt.Sprintf("scaling down from %d to %d nodes", currentNodeCount, desiredNodeCount))
+	default:
+		scalingCondition = newClusterCondition(aerospikeCluster, common.ConditionScalingInProgress, apiextensions.ConditionFalse,
+			common.ReasonNodeCountReached, fmt.Sprintf("the cluster has %d nodes", desiredNodeCount))
+	}
+
+	var configCondition aerospikev1alpha2.AerospikeClusterCondition
+	if outdatedPods > 0 {
+		configCondition = newClusterCondition(aerospikeCluster, common.ConditionConfigApplying, apiextensions.ConditionTrue,
+			common.ReasonConfigOutdated, fmt.Sprintf("%d of %d pods are running an outdated configuration", outdatedPods, len(pods)))
+	} else {
+		configCondition = newClusterCondition(aerospikeCluster, common.ConditionConfigApplying, apiextensions.ConditionFalse,
+			common.ReasonConfigApplied, "every pod is running the current configuration")
+	}
+
+	var progressingCondition aerospikev1alpha2.AerospikeClusterCondition
+	switch {
+	case aerospikeCluster.Spec.Paused:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionFalse,
+			common.ReasonReconciliationPaused, "reconciliation is paused")
+	case upgradeStatus == UpgradeStatusFailedAnnotationValue:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionFalse,
+			common.ReasonUpgradeFailed, "the upgrade has failed")
+	case upgradeStatus == UpgradeStatusRollbackFailedAnnotationValue:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionFalse,
+			common.ReasonUpgradeRollbackFailed, "the rollback of the failed upgrade has failed")
+	case upgradeStatus == UpgradeStatusPausedAnnotationValue:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionFalse,
+			common.ReasonUpgradePaused, "the upgrade has been paused")
+	case upgradeStatus == UpgradeStatusRollbackAnnotationValue:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionTrue,
+			common.ReasonUpgradeRollbackInProgress, fmt.Sprintf("rolling back the failed upgrade to version %s", aerospikeCluster.Status.Version))
+	case upgrading:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionTrue,
+			common.ReasonUpgradeInProgress, fmt.Sprintf("upgrading from version %s to %s", aerospikeCluster.Status.Version, targetVersion))
+	case scaling:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionTrue,
+			common.ReasonScalingInProgress, scalingCondition.Message)
+	case outdatedPods > 0:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionTrue,
+			common.ReasonConfigApplying, configCondition.Message)
+	case storageUpdating:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionTrue,
+			common.ReasonStorageUpdateInProgress, "the persistent volume claims are being updated")
+	case rolledBackVersion != "":
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionFalse,
+			common.ReasonUpgradeRolledBack, fmt.Sprintf("the upgrade to version %s has been rolled back", rolledBackVersion))
+	default:
+		progressingCondition = newClusterCondition(aerospikeCluster, common.ConditionProgressing, apiextensions.ConditionFalse,
+			common.ReasonReconciliationComplete, "the cluster matches its spec")
+	}
+	progressing := progressingCondition.Status == apiextensions.ConditionTrue
+
+	var degradedCondition aerospikev1alpha2.AerospikeClusterCondition
+	switch {
+	case upgradeStatus == UpgradeStatusFailedAnnotationValue:
+		degradedCondition = newClusterCondition(aerospikeCluster, common.ConditionDegraded, apiextensions.ConditionTrue,
+			common.ReasonUpgradeFailed, progressingCondition.Message)
+	case upgradeStatus == UpgradeStatusRollbackFailedAnnotationValue:
+		degradedCondition = newClusterCondition(aerospikeCluster, common.ConditionDegraded, apiextensions.ConditionTrue,
+			common.ReasonUpgradeRollbackFailed, progressingCondition.Message)
+	case reconcileErr != nil:
+		degradedCondition = newClusterCondition(aerospikeCluster, common.ConditionDegraded, apiextensions.ConditionTrue,
+			common.ReasonReconcileFailed, reconcileErr.Error())
+	// nodes are expected to be temporarily unavailable while changes are
+	// being applied
+	case nodesNotReady && !progressing:
+		degradedCondition = newClusterCondition(aerospikeCluster, common.ConditionDegraded, apiextensions.ConditionTrue,
+			common.ReasonNodesNotReady, readyMessage)
+	case integrityLost && !progressing:
+		degradedCondition = newClusterCondition(aerospikeCluster, common.ConditionDegraded, apiextensions.ConditionTrue,
+			common.ReasonClusterIntegrityLost, "the nodes do not report cluster integrity")
+	default:
+		degradedCondition = newClusterCondition(aerospikeCluster, common.ConditionDegraded, apiextensions.ConditionFalse,
+			common.ReasonClusterHealthy, "the cluster is healthy")
+	}
+
+	var readyCondition aerospikev1alpha2.AerospikeClusterCondition
+	switch {
+	case nodesNotReady:
+		readyCondition = newClusterCondition(aerospikeCluster, common.ConditionReady, apiextensions.ConditionFalse,
+			common.ReasonNodesNotReady, readyMessage)
+	case integrityLost:
+		readyCondition = newClusterCondition(aerospikeCluster, common.ConditionReady, apiextensions.ConditionFalse,
+			common.ReasonClusterIntegrityLost, "the nodes do not report cluster integrity")
+	default:
+		readyCondition = newClusterCondition(aerospikeCluster, common.ConditionReady, apiextensions.ConditionTrue,
+			common.ReasonClusterReady, readyMessage)
+	}
+
+	setCondition(aerospikeCluster, readyCondition)
+	setCondition(aerospikeCluster, progressingCondition)
+	setCondition(aerospikeCluster, degradedCondition)
+	setCondition(aerospikeCluster, scalingCondition)
+	setCondition(aerospikeCluster, configCondition)
+}
+
+// newClusterCondition returns a condition with the specified type, status,
+// reason and message computed for the current generation of aerospikeCluster.
+func newClusterCondition(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, conditionType apiextensions.CustomResourceDefinitionConditionType, status apiextensions.ConditionStatus, reason, message string) aerospikev1alpha2.AerospikeClusterCondition {
+	return aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: aerospikeCluster.Generation,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// isConditionTrue returns whether aerospikeCluster has a condition of the
+// specified type whose status is True.
+func isConditionTrue(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, conditionType apiextensions.CustomResourceDefinitionConditionType) bool {
+	c := getCondition(aerospikeCluster, conditionType)
+	return c != nil && c.Status == apiextensions.ConditionTrue
+}
@@ -0,0 +1,259 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func TestSetClusterConditions(t *testing.T) {
+	readyPods := []*corev1.Pod{
+		newTestPod("as-cluster-0-0", "10.0.0.1", "a1", true),
+		newTestPod("as-cluster-0-1", "10.0.0.2", "a2", true),
+	}
+	notReadyPods := []*corev1.Pod{
+		newTestPod("as-cluster-0-0", "10.0.0.1", "a1", true),
+		newTestPod("as-cluster-0-1", "10.0.0.2", "a2", false),
+	}
+
+	tests := []struct {
+		name         string
+		mutate       func(*aerospikev1alpha2.AerospikeCluster)
+		pods         []*corev1.Pod
+		outdatedPods int
+		err          error
+		expected     map[apiextensions.CustomResourceDefinitionConditionType]string
+	}{
+		{
+			name:   "healthy",
+			mutate: func(*aerospikev1alpha2.AerospikeCluster) {},
+			pods:   readyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:             common.ReasonClusterReady,
+				common.ConditionProgressing:       common.ReasonReconciliationComplete,
+				common.ConditionDegraded:          common.ReasonClusterHealthy,
+				common.ConditionScalingInProgress: common.ReasonNodeCountReached,
+				common.ConditionConfigApplying:    common.ReasonConfigApplied,
+			},
+		},
+		{
+			name: "scaling up",
+			mutate: func(asc *aerospikev1alpha2.AerospikeCluster) {
+				asc.Spec.NodeCount = 3
+			},
+			pods: readyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:             common.ReasonNodesNotReady,
+				common.ConditionProgressing:       common.ReasonScalingInProgress,
+				common.ConditionDegraded:          common.ReasonClusterHealthy,
+				common.ConditionScalingInProgress: common.ReasonScalingUp,
+				common.ConditionConfigApplying:    common.ReasonConfigApplied,
+			},
+		},
+		{
+			name:         "applying configuration",
+			mutate:       func(*aerospikev1alpha2.AerospikeCluster) {},
+			pods:         notReadyPods,
+			outdatedPods: 1,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:             common.ReasonNodesNotReady,
+				common.ConditionProgressing:       common.ReasonConfigApplying,
+				common.ConditionDegraded:          common.ReasonClusterHealthy,
+				common.ConditionScalingInProgress: common.ReasonNodeCountReached,
+				common.ConditionConfigApplying:    common.ReasonConfigOutdated,
+			},
+		},
+		{
+			name:   "node not ready",
+			mutate: func(*aerospikev1alpha2.AerospikeCluster) {},
+			pods:   notReadyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:       common.ReasonNodesNotReady,
+				common.ConditionProgressing: common.ReasonReconciliationComplete,
+				common.ConditionDegraded:    common.ReasonNodesNotReady,
+			},
+		},
+		{
+			name: "upgrade failed",
+			mutate: func(asc *aerospikev1alpha2.AerospikeCluster) {
+				asc.Spec.Version = "4.6.0.2"
+				asc.Annotations = map[string]string{UpgradeStatusAnnotationKey: UpgradeStatusFailedAnnotationValue}
+			},
+			pods: readyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:       common.ReasonClusterReady,
+				common.ConditionProgressing: common.ReasonUpgradeFailed,
+				common.ConditionDegraded:    common.ReasonUpgradeFailed,
+			},
+		},
+		{
+			name: "rolling back a failed upgrade",
+			mutate: func(asc *aerospikev1alpha2.AerospikeCluster) {
+				asc.Annotations = map[string]string{
+					UpgradeStatusAnnotationKey:            UpgradeStatusRollbackAnnotationValue,
+					UpgradeRolledBackVersionAnnotationKey: "4.6.0.2",
+				}
+			},
+			pods: readyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:       common.ReasonClusterReady,
+				common.ConditionProgressing: common.ReasonUpgradeRollbackInProgress,
+				common.ConditionDegraded:    common.ReasonClusterHealthy,
+			},
+		},
+		{
+			name: "upgrade rolled back",
+			mutate: func(asc *aerospikev1alpha2.AerospikeCluster) {
+				asc.Annotations = map[string]string{UpgradeRolledBackVersionAnnotationKey: "4.6.0.2"}
+			},
+			pods: readyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:       common.ReasonClusterReady,
+				common.ConditionProgressing: common.ReasonUpgradeRolledBack,
+				common.ConditionDegraded:    common.ReasonClusterHealthy,
+			},
+		},
+		{
+			name: "between hops of a multi-hop upgrade",
+			mutate: func(asc *aerospikev1alpha2.AerospikeCluster) {
+				// the version in the spec has been replaced with the target
+				// version of the hop that has just finished
+				asc.Spec.Version = "4.5.3.2"
+				asc.Status.UpgradePlan = &aerospikev1alpha2.UpgradePlanStatus{
+					Versions:   []string{"4.2.0.10", "4.5.3.2", "4.6.0.2"},
+					CurrentHop: 1,
+				}
+			},
+			pods: readyPods,
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:       common.ReasonClusterReady,
+				common.ConditionProgressing: common.ReasonUpgradeInProgress,
+				common.ConditionDegraded:    common.ReasonClusterHealthy,
+			},
+		},
+		{
+			name:   "reconcile failed",
+			mutate: func(*aerospikev1alpha2.AerospikeCluster) {},
+			pods:   readyPods,
+			err:    fmt.Errorf("failed"),
+			expected: map[apiextensions.CustomResourceDefinitionConditionType]string{
+				common.ConditionReady:    common.ReasonClusterReady,
+				common.ConditionDegraded: common.ReasonReconcileFailed,
+			},
+		},
+	}
+	for _, test := range tests {
+		aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+		aerospikeCluster.Generation = 3
+		aerospikeCluster.Status.Version = "4.5.3.2"
+		aerospikeCluster.Status.NodeCount = 2
+		test.mutate(aerospikeCluster)
+		setClusterConditions(aerospikeCluster, test.pods, test.outdatedPods, test.err)
+
+		for conditionType, reason := range test.expected {
+			c := getCondition(aerospikeCluster, conditionType)
+			if assert.NotNil(t, c, "%s: %s", test.name, conditionType) {
+				assert.Equal(t, reason, c.Reason, "%s: %s", test.name, conditionType)
+				assert.Equal(t, int64(3), c.ObservedGeneration, "%s: %s", test.name, conditionType)
+			}
+		}
+	}
+}
+
+func TestSetClusterConditionsUpgradeMessage(t *testing.T) {
+	pods := []*corev1.Pod{
+		newTestPod("as-cluster-0-0", "10.0.0.1", "a1", true),
+		newTestPod("as-cluster-0-1", "10.0.0.2", "a2", true),
+	}
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	aerospikeCluster.Status.Version = "4.2.0.10"
+	aerospikeCluster.Status.NodeCount = 2
+	aerospikeCluster.Status.UpgradePlan = &aerospikev1alpha2.UpgradePlanStatus{
+		Versions:   []string{"4.2.0.10", "4.5.3.2", "4.6.0.2"},
+		CurrentHop: 1,
+	}
+
+	setClusterConditions(aerospikeCluster, pods, 0, nil)
+	// the version requested by the user must be reported rather than the
+	// target version of the current hop
+	assert.Equal(t, "upgrading from version 4.2.0.10 to 4.6.0.2", getCondition(aerospikeCluster, common.ConditionProgressing).Message)
+}
+
+func TestSetClusterConditionsInPlace(t *testing.T) {
+	pods := []*corev1.Pod{
+		newTestPod("as-cluster-0-0", "10.0.0.1", "a1", true),
+		newTestPod("as-cluster-0-1", "10.0.0.2", "a2", true),
+	}
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	aerospikeCluster.Status.Version = "4.5.3.2"
+	aerospikeCluster.Status.NodeCount = 2
+
+	setClusterConditions(aerospikeCluster, pods, 0, nil)
+	ready := *getCondition(aerospikeCluster, common.ConditionReady)
+	setClusterConditions(aerospikeCluster, pods, 0, fmt.Errorf("failed"))
+	setClusterConditions(aerospikeCluster, pods, 0, nil)
+
+	// conditions must be replaced rather than appended
+	assert.Len(t, aerospikeCluster.Status.Conditions, 5)
+	// the last transition time must be kept while the status does not change
+	assert.Equal(t, ready.LastTransitionTime, getCondition(aerospikeCluster, common.ConditionReady).LastTransitionTime)
+	assert.Equal(t, apiextensions.ConditionFalse, getCondition(aerospikeCluster, common.ConditionDegraded).Status)
+}
+
+func TestSetConditionCollapsesDuplicates(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	// conditions appended by previous versions of aerospike-operator
+	aerospikeCluster.Status.Conditions = []aerospikev1alpha2.AerospikeClusterCondition{
+		{Type: common.ConditionUpgradeStarted, Status: apiextensions.ConditionTrue},
+		{Type: common.ConditionUpgradeFinished, Status: apiextensions.ConditionTrue},
+		{Type: common.ConditionUpgradeStarted, Status: apiextensions.ConditionTrue},
+	}
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:   common.ConditionUpgradeStarted,
+		Status: apiextensions.ConditionTrue,
+		Reason: "Test",
+	})
+	assert.Len(t, aerospikeCluster.Status.Conditions, 2)
+	assert.Equal(t, "Test", getCondition(aerospikeCluster, common.ConditionUpgradeStarted).Reason)
+}
+
+func TestClearConditions(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:    common.ConditionUpgradeStarted,
+		Status:  apiextensions.ConditionTrue,
+		Reason:  "ClusterUpgradeStarted",
+		Message: "upgrade from version 4.5.3.2 to 4.6.0.2 started",
+	})
+
+	clearConditions(aerospikeCluster, common.ConditionUpgradeFinished, common.ConditionUpgradeStarted, common.ConditionUpgradeFailed)
+	// conditions which do not exist must not be created
+	assert.Len(t, aerospikeCluster.Status.Conditions, 1)
+	c := getCondition(aerospikeCluster, common.ConditionUpgradeStarted)
+	assert.Equal(t, apiextensions.ConditionFalse, c.Status)
+	assert.Equal(t, common.ReasonSuperseded, c.Reason)
+	assert.Equal(t, "superseded by UpgradeFinished", c.Message)
+}
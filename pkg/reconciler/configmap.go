@@ -18,8 +18,10 @@ package reconciler
 
 import (
 	"bytes"
+	"fmt"
 	"strconv"
 	"strings"
+	"text/template"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
@@ -34,6 +36,7 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
 	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
 	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
 )
 
 func (r *AerospikeClusterReconciler) ensureConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (*v1.ConfigMap, error) {
@@ -91,17 +94,62 @@ func (r *AerospikeClusterReconciler) updateConfigMap(aerospikeCluster *aerospike
 	}
 }
 
+// configTemplates holds the templates used to generate aerospike.conf for a
+// family of versions of aerospike.
+type configTemplates struct {
+	// config is the template of the whole configuration file
+	config *template.Template
+	// namespace is the template of the configuration of a single namespace
+	namespace *template.Template
+}
+
+// newConfigTemplates returns the templates used to generate aerospike.conf
+// using the specified fragments for the service and xdr stanzas and for the
+// xdr configuration of a namespace.
+func newConfigTemplates(service, xdr, namespaceXDR string) *configTemplates {
+	config := template.Must(template.New("aerospike-config").Parse(aerospikeConfig))
+	template.Must(config.New(serviceConfigFragment).Parse(service))
+	template.Must(config.New(xdrConfigFragment).Parse(xdr))
+	namespace := template.Must(template.New("as-namespace-config").Parse(aerospikeNamespaceConfig))
+	template.Must(namespace.New(namespaceXDRConfigFragment).Parse(namespaceXDR))
+	return &configTemplates{
+		config:    config,
+		namespace: namespace,
+	}
+}
+
+// getConfigTemplates returns the templates used to generate aerospike.conf
+// for the version of aerospike specified in the spec of aerospikeCluster.
+func getConfigTemplates(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *configTemplates {
+	version, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	if err != nil {
+		// should not happen, as the version is validated by the admission
+		// webhook
+		return asConfigTemplates
+	}
+	switch {
+	case !version.SupportsXDRDigestLog():
+		return as50ConfigTemplates
+	case !version.SupportsTransactionQueues():
+		return as47ConfigTemplates
+	default:
+		return asConfigTemplates
+	}
+}
+
 func buildConfig(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
 	var namespacesConfig []string
 
+	templates := getConfigTemplates(aerospikeCluster)
+
 	for index, namespace := range aerospikeCluster.Spec.Namespaces {
 		buf := new(bytes.Buffer)
-		asNamespaceTemplate.Execute(buf, getNamespaceProps(aerospikeCluster, index, &namespace))
+		templates.namespace.Execute(buf, getNamespaceProps(aerospikeCluster, index, &namespace))
 		namespacesConfig = append(namespacesConfig, buf.String())
 	}
 
 	configMapBuffer := new(bytes.Buffer)
-	asConfigTemplate.Execute(configMapBuffer, getClusterProps(aerospikeCluster, namespacesConfig))
+	templates.config.Execute(configMapBuffer, getClusterProps(aerospikeCluster, namespacesConfig))
 
 	return configMapBuffer.String()
 }
@@ -137,11 +185,15 @@ func buildConfigMap(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *v1.Co
 }
 
 func getClusterProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespacesConfig []string) map[string]interface{} {
-	return map[string]interface{}{
+	props := map[string]interface{}{
 		serviceNodeIdKey:            ServiceNodeIdValue,
 		clusterNamespacesKey:        namespacesConfig,
 		heartbeatAddressesConfigKey: HeartbeatAddressesValue,
 	}
+	if aerospikeCluster.Spec.XDR != nil {
+		props[xdrKey] = getXDRProps(aerospikeCluster)
+	}
+	return props
 }
 
 func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, index int, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) map[string]interface{} {
@@ -174,14 +226,31 @@ func getNamespaceProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, ind
 
 	if namespace.Storage.Type == common.StorageTypeFile {
 		props[nsStorageSizeKey] = namespace.Storage.Size
-		props[nsFilePath] = defaultFilePath
+		filePaths := make([]string, 0, getNamespaceVolumeCount(namespace))
+		for i := 0; i < getNamespaceVolumeCount(namespace); i++ {
+			filePaths = append(filePaths, getNamespaceFilePath(namespace.Name, i))
+		}
+		props[nsFilePaths] = filePaths
 	} else if namespace.Storage.Type == common.StorageTypeDevice {
-		props[nsDevicePath] = getIndexBasedDevicePath(index)
+		devicePaths := make([]string, 0, getNamespaceVolumeCount(namespace))
+		for i := 0; i < getNamespaceVolumeCount(namespace); i++ {
+			devicePath := getIndexBasedDevicePath(aerospikeCluster, index, i)
+			// the shadow device is specified right after the device it shadows
+			if hasShadowDevices(namespace) {
+				devicePath = fmt.Sprintf("%s %s", devicePath, getIndexBasedDevicePath(aerospikeCluster, index, getNamespaceVolumeCount(namespace)+i))
+			}
+			devicePaths = append(devicePaths, devicePath)
+		}
+		props[nsDevicePaths] = devicePaths
 	}
 
 	if namespace.Storage.DataInMemory != nil {
 		props[nsDataInMemory] = *namespace.Storage.DataInMemory
 	}
 
+	if datacenters := getXDRRemoteDatacenters(aerospikeCluster, namespace.Name); len(datacenters) > 0 {
+		props[nsXDRRemoteDatacenters] = datacenters
+	}
+
 	return props
 }
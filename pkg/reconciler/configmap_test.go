@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// newTestAerospikeCluster returns an AerospikeCluster resource running the
+// specified version, with a namespace stored in files and shipped to a remote
+// datacenter.
+func newTestAerospikeCluster(version string) *aerospikev1alpha2.AerospikeCluster {
+	return &aerospikev1alpha2.AerospikeCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "as-cluster-0",
+			Namespace: "kubernetes-namespace-0",
+		},
+		Spec: aerospikev1alpha2.AerospikeClusterSpec{
+			Version:   version,
+			NodeCount: 2,
+			Namespaces: []aerospikev1alpha2.AerospikeNamespaceSpec{
+				{
+					Name:              "as-namespace-0",
+					ReplicationFactor: pointers.NewInt32(2),
+					MemorySize:        pointers.NewString("1G"),
+					DefaultTTL:        pointers.NewString("0s"),
+					Storage: aerospikev1alpha2.StorageSpec{
+						Type: common.StorageTypeFile,
+						Size: "1G",
+					},
+				},
+			},
+			XDR: &aerospikev1alpha2.XDRSpec{
+				Destinations: []aerospikev1alpha2.XDRDestinationSpec{
+					{
+						Name:          "dc-1",
+						SeedAddresses: []string{"10.0.0.1:3000", "10.0.0.2:3000"},
+						Namespaces:    []string{"as-namespace-0"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildConfig(t *testing.T) {
+	tests := []struct {
+		version string
+		golden  string
+	}{
+		{"4.3.0.10", "aerospike-4.3.conf"},
+		{"4.5.3.2", "aerospike-4.5.conf"},
+		{"4.9.0.3", "aerospike-4.9.conf"},
+		{"5.0.0.4", "aerospike-5.0.conf"},
+	}
+	for _, test := range tests {
+		config := buildConfig(newTestAerospikeCluster(test.version))
+		path := filepath.Join("testdata", test.golden)
+		if *update {
+			assert.NoError(t, ioutil.WriteFile(path, []byte(config), 0644))
+		}
+		expected, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), config, test.version)
+	}
+}
+
+// TestBuildConfigWithoutXDR checks that the configuration of an aerospike
+// cluster which uses none of the features added since 0.10 is byte-identical
+// to the one generated by previous versions of aerospike-operator, so that
+// upgrading aerospike-operator does not restart every pod. The golden file was
+// generated by 0.10 and must not be updated.
+func TestBuildConfigWithoutXDR(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.3.0.10")
+	aerospikeCluster.Spec.XDR = nil
+	aerospikeCluster.Spec.Namespaces = append(aerospikeCluster.Spec.Namespaces, aerospikev1alpha2.AerospikeNamespaceSpec{
+		Name:              "as-namespace-1",
+		ReplicationFactor: pointers.NewInt32(1),
+		Storage: aerospikev1alpha2.StorageSpec{
+			Type:         common.StorageTypeDevice,
+			Size:         "1G",
+			DataInMemory: pointers.NewBool(true),
+		},
+	})
+	expected, err := ioutil.ReadFile(filepath.Join("testdata", "aerospike-4.3-baseline.conf"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), buildConfig(aerospikeCluster))
+}
@@ -17,7 +17,6 @@ limitations under the License.
 package reconciler
 
 import (
-	"text/template"
 	"time"
 )
 
@@ -39,6 +38,11 @@ const (
 
 	namespaceVolumePrefix = "data-ns"
 
+	// the names of the containers that make up an aerospike pod
+	initContainerName   = "init"
+	serverContainerName = "aerospike-server"
+	aspromContainerName = "asprom"
+
 	ServicePort       = 3000
 	servicePortName   = "service"
 	HeartbeatPort     = 3002
@@ -56,13 +60,43 @@ const (
 	// the correct cluster size before forcibly deleting it
 	waitClusterSizeTimeout = 1 * time.Minute
 
+	// quiesceTimeout is how long we will wait for a quiesced node to hand
+	// off its partitions before proceeding with its deletion
+	quiesceTimeout = 5 * time.Minute
+	// quiescePollInterval is the interval at which we check whether a
+	// quiesced node has handed off its partitions
+	quiescePollInterval = 5 * time.Second
+
 	podOperationFeedbackPeriod = 2 * time.Minute
 	aerospikeClientTimeout     = 10 * time.Second
 
+	// observedStatusRefreshInterval is the minimum interval between two
+	// consecutive updates of the observed state of the aerospike nodes in the
+	// status of an aerospikecluster
+	observedStatusRefreshInterval = 20 * time.Second
+	// monitoringResourcesRefreshInterval is the minimum interval between two
+	// consecutive checks of the resources of the prometheus operator served
+	// by the api server
+	monitoringResourcesRefreshInterval = 5 * time.Minute
+
 	// the name of the annotation that holds the hash of the mounted configmap
 	configMapHashAnnotation = "aerospike.travelaudience.com/config-map-hash"
-	// the name of the annotation that holds the aerospike node id
+	// the name of the annotation that holds the hash of the pod
+	// customizations specified in the aerospikecluster resource
+	podSpecHashAnnotation = "aerospike.travelaudience.com/pod-spec-hash"
+	// the name of the annotation that holds the aerospike node id (of a pod, or
+	// of the pod whose data is held by a PVC)
 	nodeIdAnnotation = "aerospike.travelaudience.com/node-id"
+	// the name of the annotation that holds the timestamp at which the soak
+	// period of the canary pods of an aerospikecluster started
+	canarySoakStartedOnAnnotation = "aerospike.travelaudience.com/canary-soak-started-on"
+	// the name of the annotation that holds the number of client errors
+	// reported by the canary pods of an aerospikecluster when the soak period
+	// started
+	canarySoakClientErrorsAnnotation = "aerospike.travelaudience.com/canary-soak-client-errors"
+	// the name of the annotation that holds the timestamp at which the last
+	// group of pods of an aerospikecluster was restarted during a rollout
+	lastRolloutRestartOnAnnotation = "aerospike.travelaudience.com/last-rollout-restart-on"
 	// the name of the annotation that holds the name of the pod with which a
 	// PVC is associated
 	PodAnnotation = "aerospike.travelaudience.com/pod-name"
@@ -72,6 +106,12 @@ const (
 	// the name of the annotation that holds the timestamp at which a PVC
 	// was last unmounted from a pod
 	LastUnmountedOnAnnotation = "aerospike.travelaudience.com/last-unmounted-on"
+	// the name of the annotation that holds the index of the volume held by a
+	// PVC among the volumes of its namespace
+	VolumeIndexAnnotation = "aerospike.travelaudience.com/volume-index"
+	// the name of the annotation that holds the timestamp at which a PVC was
+	// replaced by a new one (e.g. due to a change in the storage spec)
+	ReplacedOnAnnotation = "aerospike.travelaudience.com/replaced-on"
 
 	// the name of the key that corresponds to the service.node-id property
 	// (used for templating)
@@ -92,16 +132,42 @@ const (
 	nsDefaultTTLKey        = "defaultTTL"
 	nsStorageTypeKey       = "storageType"
 	nsStorageSizeKey       = "storageSize"
-	nsFilePath             = "filePath"
-	nsDevicePath           = "devicePath"
+	nsFilePaths            = "filePaths"
+	nsDevicePaths          = "devicePaths"
 	nsDataInMemory         = "dataInMemory"
-
-	aspromPortName      = "prometheus"
-	aspromPort          = 9145
-	aspromCpuRequest    = "10m"
-	aspromMemoryRequest = "32Mi"
-	aspromCpuLimit      = "10m"
-	aspromMemoryLimit   = "64Mi"
+	nsXDRRemoteDatacenters = "xdrRemoteDatacenters"
+
+	xdrKey                        = "xdr"
+	xdrDigestLogPathKey           = "digestLogPath"
+	xdrDigestLogSizeKey           = "digestLogSize"
+	xdrDatacentersKey             = "datacenters"
+	xdrDatacenterNameKey          = "name"
+	xdrDatacenterNodeAddressesKey = "nodeAddresses"
+	xdrDatacenterNamespacesKey    = "namespaces"
+
+	// the name under which the persistent volume claims holding the xdr
+	// digest log are managed alongside those of the aerospike namespaces
+	xdrDigestLogNamespaceName = "xdr-digestlog"
+	// the space (gibibytes) requested for the persistent volume holding the
+	// xdr digest log on top of the size of the digest log itself, so that it
+	// fits in the volume despite the overhead of the filesystem
+	xdrDigestLogVolumeOverheadGi = 1
+	// the name of the xdr digest log file
+	xdrDigestLogFileName = "digestlog"
+	// the default size of the xdr digest log
+	defaultXDRDigestLogSize = "1G"
+
+	aspromPortName = "prometheus"
+	aspromPort     = 9145
+	aspromPath     = "/metrics"
+	// the default resource requests and limits of the asprom container, used
+	// unless .spec.monitoring.exporter.resources is specified
+	aspromDefaultCpuRequest    = "10m"
+	aspromDefaultMemoryRequest = "32Mi"
+	aspromDefaultCpuLimit      = "10m"
+	aspromDefaultMemoryLimit   = "64Mi"
+	// default value for monitoring.prometheusRule.migrationsStuckAfter
+	defaultMigrationsStuckAfter = "30m"
 
 	asReadinessInitialDelaySeconds = 3
 	asReadinessTimeoutSeconds      = 2
@@ -131,6 +197,31 @@ const (
 	// UpgradeStatusBackupAnnotationValue is the value of the annotation added
 	// to AerospikeCluster resources that are undergoing a pre-upgrade backup.
 	UpgradeStatusBackupAnnotationValue = "backup"
+	// UpgradeStatusPausedAnnotationValue is the value of the annotation added
+	// to AerospikeCluster resources whose upgrade has been halted because the
+	// canary pods have failed a health check.
+	UpgradeStatusPausedAnnotationValue = "paused"
+	// UpgradeStatusRollbackAnnotationValue is the value of the annotation
+	// added to AerospikeCluster resources that are being rolled back after a
+	// failed upgrade.
+	UpgradeStatusRollbackAnnotationValue = "rollback"
+	// UpgradeStatusRollbackFailedAnnotationValue is the value of the
+	// annotation added to AerospikeCluster resources that have not been
+	// successfully rolled back after a failed upgrade.
+	UpgradeStatusRollbackFailedAnnotationValue = "rollback-failed"
+	// UpgradeRollbackFromAnnotationKey is the name of the annotation holding
+	// the version of a failed upgrade that is being rolled back, and whose
+	// pre-upgrade backup must be restored.
+	UpgradeRollbackFromAnnotationKey = "aerospike.travelaudience.com/rollback-from"
+	// UpgradeRolledBackVersionAnnotationKey is the name of the annotation
+	// holding the requested version of a failed upgrade that has been rolled
+	// back. AerospikeCluster resources are kept at the version they are
+	// running for as long as .spec.version matches this annotation.
+	UpgradeRolledBackVersionAnnotationKey = "aerospike.travelaudience.com/rolled-back-version"
+	// UpgradeRetriedVersionAnnotationKey is the name of the annotation holding
+	// the requested version of a failed upgrade that has been retried, so that
+	// the upgrade is retried only once.
+	UpgradeRetriedVersionAnnotationKey = "aerospike.travelaudience.com/retried-version"
 
 	// terminal state reasons when pod status is Pending
 	// container image pull failed
@@ -144,27 +235,48 @@ const (
 
 	// default value for persistentVolumeClaimTTL
 	defaultPersistentVolumeClaimTTL = "0d"
+	// the value of persistentVolumeClaimTTL used for replaced PVCs that would
+	// otherwise be kept forever
+	replacedPersistentVolumeClaimTTL = "1d"
+	// default value for canaryUpgrade.podCount
+	defaultCanaryPodCount = 1
+	// default value for canaryUpgrade.soakPeriod
+	defaultCanarySoakPeriod = "10m"
+	// the interval at which the health of canary pods is checked during the
+	// soak period
+	canaryHealthCheckInterval = 10 * time.Second
+	// default value for localVolumeReleaseTimeout
+	defaultLocalVolumeReleaseTimeout = "30m"
 
 	// default value for memory-size, corresponding to the default used by aerospike in versions prior to 4.3.0.2
 	// https://www.aerospike.com/docs/reference/configuration/#memory-size
 	defaultMemorySize = "4G"
 )
 
-var asConfigTemplate = template.Must(template.New("aerospike-config").Parse(aerospikeConfig))
-var asNamespaceTemplate = template.Must(template.New("as-namespace-config").Parse(aerospikeNamespaceConfig))
+var (
+	// asConfigTemplates holds the templates used to generate aerospike.conf
+	// for versions of aerospike prior to 4.7
+	asConfigTemplates = newConfigTemplates(aerospikeServiceConfig, aerospikeXDRConfig, aerospikeNamespaceXDRConfig)
+	// as47ConfigTemplates holds the templates used to generate aerospike.conf
+	// for versions of aerospike from 4.7 and prior to 5.0, which no longer
+	// support transaction queues
+	as47ConfigTemplates = newConfigTemplates(aerospike47ServiceConfig, aerospikeXDRConfig, aerospikeNamespaceXDRConfig)
+	// as50ConfigTemplates holds the templates used to generate aerospike.conf
+	// for versions of aerospike from 5.0, in which xdr is configured per
+	// datacenter and no longer uses a digest log
+	as50ConfigTemplates = newConfigTemplates(aerospike47ServiceConfig, aerospike50XDRConfig, "")
+)
+
+// the names of the template fragments which differ between versions of
+// aerospike
+const (
+	serviceConfigFragment      = "service"
+	xdrConfigFragment          = "xdr"
+	namespaceXDRConfigFragment = "namespace-xdr"
+)
 
 const aerospikeConfig = `
-service {
-	user root
-	group root
-	paxos-single-replica-limit 1
-	pidfile /var/run/aerospike/asd.pid
-	service-threads 4
-	transaction-queues 4
-	transaction-threads-per-queue 4
-	proto-fd-max 15000
-	node-id {{.nodeId}}
-}
+{{template "service" .}}
 
 logging {
 	file /var/log/aerospike/aerospike.log {
@@ -200,12 +312,68 @@ network {
 		port 3003
 	}
 }
+{{- template "xdr" .}}
 
 {{range .namespaces}}
 	{{.}}
 {{end}}
 `
 
+const aerospikeServiceConfig = `service {
+	user root
+	group root
+	paxos-single-replica-limit 1
+	pidfile /var/run/aerospike/asd.pid
+	service-threads 4
+	transaction-queues 4
+	transaction-threads-per-queue 4
+	proto-fd-max 15000
+	node-id {{.nodeId}}
+}`
+
+const aerospike47ServiceConfig = `service {
+	user root
+	group root
+	paxos-single-replica-limit 1
+	pidfile /var/run/aerospike/asd.pid
+	service-threads 4
+	proto-fd-max 15000
+	node-id {{.nodeId}}
+}`
+
+const aerospikeXDRConfig = `{{- if .xdr}}
+
+xdr {
+	enable-xdr true
+	xdr-digestlog-path {{.xdr.digestLogPath}} {{.xdr.digestLogSize}}
+
+	{{range .xdr.datacenters}}
+	datacenter {{.name}} {
+		{{range .nodeAddresses}}
+		dc-node-address-port {{.}}
+		{{end}}
+	}
+	{{end}}
+}
+{{- end}}`
+
+const aerospike50XDRConfig = `{{- if .xdr}}
+
+xdr {
+	{{range .xdr.datacenters}}
+	dc {{.name}} {
+		{{range .nodeAddresses}}
+		node-address-port {{.}}
+		{{end}}
+		{{range .namespaces}}
+		namespace {{.}} {
+		}
+		{{end}}
+	}
+	{{end}}
+}
+{{- end}}`
+
 const aerospikeNamespaceConfig = `
 namespace {{.name}} {
 
@@ -221,12 +389,19 @@ namespace {{.name}} {
 		default-ttl {{.defaultTTL}}
 	{{end}}
 
+	{{if eq .storageType "memory" -}}
+	storage-engine memory
+	{{- else -}}
 	storage-engine device {
 
 		{{if eq .storageType "file"}}
-			file {{.filePath}}{{.name}}/{{.name}}.dat
+			{{- range .filePaths}}
+			file {{.}}
+			{{- end}}
 		{{else if eq .storageType "device"}}
-			device {{.devicePath}}
+			{{- range .devicePaths}}
+			device {{.}}
+			{{- end}}
 		{{end}}
 
 		{{if .storageSize}}
@@ -237,4 +412,14 @@ namespace {{.name}} {
 			data-in-memory {{.dataInMemory}}
 		{{- end}}
 	}
+	{{- end}}
+	{{- template "namespace-xdr" .}}
 }`
+
+const aerospikeNamespaceXDRConfig = `{{- if .xdrRemoteDatacenters}}
+
+	enable-xdr true
+	{{- range .xdrRemoteDatacenters}}
+	xdr-remote-datacenter {{.}}
+	{{- end}}
+{{- end}}`
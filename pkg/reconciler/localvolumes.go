@@ -0,0 +1,233 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
+)
+
+// maybeReleaseLocalPersistentVolumeClaims checks whether pod cannot be
+// scheduled because the node holding any of its local persistent volumes is
+// gone. If that is the case and the pod has been pending for longer than the
+// localVolumeReleaseTimeout of the affected namespaces, the persistent volume
+// claims bound to the lost volumes are marked as replaced and the pod is
+// deleted, so that it is re-created with new persistent volume claims and has
+// its data migrated back from the remaining nodes. It returns whether the pod
+// has been deleted.
+func (r *AerospikeClusterReconciler) maybeReleaseLocalPersistentVolumeClaims(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) (bool, error) {
+	pendingSince := getUnschedulableSince(pod)
+	if pendingSince == nil {
+		return false, nil
+	}
+
+	mounted, err := r.getMountedPersistentVolumeClaims(aerospikeCluster, pod)
+	if err != nil {
+		return false, err
+	}
+
+	// find the pvcs bound to local persistent volumes whose node is gone, as
+	// well as the shortest timeout after which they may be released
+	var (
+		lost    []*corev1.PersistentVolumeClaim
+		timeout time.Duration
+	)
+	for _, m := range mounted {
+		releaseTimeout, err := getLocalVolumeReleaseTimeout(m.namespace)
+		if err != nil {
+			return false, err
+		}
+		// a timeout of zero disables releasing pvcs
+		if releaseTimeout == 0 {
+			continue
+		}
+		isLost, err := r.isLocalPersistentVolumeLost(m.pvc)
+		if err != nil {
+			return false, err
+		}
+		if !isLost {
+			continue
+		}
+		if len(lost) == 0 || releaseTimeout < timeout {
+			timeout = releaseTimeout
+		}
+		lost = append(lost, m.pvc)
+	}
+	if len(lost) == 0 {
+		return false, nil
+	}
+
+	// wait for the timeout to elapse before releasing the pvcs, as the node may
+	// only be temporarily unavailable
+	if remaining := pendingSince.Add(timeout).Sub(time.Now()); remaining > 0 {
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonLocalPersistentVolumeLost,
+			"pod %s cannot be scheduled as the node holding persistentvolumeclaim %s is gone, releasing in %s",
+			meta.Key(pod), lost[0].Name, remaining.Round(time.Second))
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+			logfields.Pod:                   meta.Key(pod),
+			logfields.PersistentVolumeClaim: lost[0].Name,
+		}).Warnf("node holding local persistentvolume is gone, releasing in %s", remaining.Round(time.Second))
+		return false, nil
+	}
+
+	// mark the pvcs as replaced so that they are not reused, and delete the
+	// pod so that it is re-created with new pvcs
+	for _, pvc := range lost {
+		if err := r.markPersistentVolumeClaimReplaced(aerospikeCluster, pod, pvc); err != nil {
+			return false, err
+		}
+	}
+	if err := r.deletePod(aerospikeCluster, pod); err != nil {
+		return false, err
+	}
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonLocalPersistentVolumeClaimsReleased,
+		"released %d persistentvolumeclaim(s) of pod %s bound to lost local persistentvolumes",
+		len(lost), meta.Key(pod))
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.Pod:              meta.Key(pod),
+	}).Warnf("released %d persistentvolumeclaim(s) bound to lost local persistentvolumes", len(lost))
+	return true, nil
+}
+
+// isLocalPersistentVolumeLost returns whether pvc is bound to a persistent
+// volume that is only accessible from a given set of nodes (e.g. a local
+// persistent volume) none of which exists anymore.
+func (r *AerospikeClusterReconciler) isLocalPersistentVolumeLost(pvc *corev1.PersistentVolumeClaim) (bool, error) {
+	if pvc.Spec.VolumeName == "" {
+		return false, nil
+	}
+	pv, err := r.pvsLister.Get(pvc.Spec.VolumeName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return false, nil
+	}
+	// node selector terms are ORed, so the volume is lost only if no node
+	// matches any of them
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		exists, err := r.nodeExistsForSelectorTerm(term)
+		if err != nil {
+			return false, err
+		}
+		if exists {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// nodeExistsForSelectorTerm returns whether there is at least one node matching
+// the specified node selector term. Terms which cannot be evaluated are assumed
+// to match an existing node.
+func (r *AerospikeClusterReconciler) nodeExistsForSelectorTerm(term corev1.NodeSelectorTerm) (bool, error) {
+	selector, err := nodeSelectorRequirementsAsSelector(term.MatchExpressions)
+	if err != nil {
+		return true, nil
+	}
+	nodeName := ""
+	for _, req := range term.MatchFields {
+		// metadata.name is the only field supported in node selector terms
+		if req.Key != "metadata.name" || req.Operator != corev1.NodeSelectorOpIn || len(req.Values) != 1 {
+			return true, nil
+		}
+		nodeName = req.Values[0]
+	}
+	if nodeName != "" {
+		node, err := r.nodesLister.Get(nodeName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		return selector.Matches(labels.Set(node.Labels)), nil
+	}
+	nodes, err := r.nodesLister.List(selector)
+	if err != nil {
+		return false, err
+	}
+	return len(nodes) > 0, nil
+}
+
+// nodeSelectorRequirementsAsSelector converts the specified node selector
+// requirements into a label selector.
+func nodeSelectorRequirementsAsSelector(reqs []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	operators := map[corev1.NodeSelectorOperator]selection.Operator{
+		corev1.NodeSelectorOpIn:           selection.In,
+		corev1.NodeSelectorOpNotIn:        selection.NotIn,
+		corev1.NodeSelectorOpExists:       selection.Exists,
+		corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+		corev1.NodeSelectorOpGt:           selection.GreaterThan,
+		corev1.NodeSelectorOpLt:           selection.LessThan,
+	}
+	selector := labels.NewSelector()
+	for _, req := range reqs {
+		op, ok := operators[req.Operator]
+		if !ok {
+			return nil, fmt.Errorf("unsupported node selector operator %q", req.Operator)
+		}
+		requirement, err := labels.NewRequirement(req.Key, op, req.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*requirement)
+	}
+	return selector, nil
+}
+
+// getUnschedulableSince returns the time since which pod has been waiting to be
+// scheduled, or nil if it has already been scheduled.
+func getUnschedulableSince(pod *corev1.Pod) *time.Time {
+	if pod.Status.Phase != corev1.PodPending || pod.Spec.NodeName != "" {
+		return nil
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodScheduled && c.Status == corev1.ConditionFalse {
+			return &c.LastTransitionTime.Time
+		}
+	}
+	return &pod.CreationTimestamp.Time
+}
+
+// getLocalVolumeReleaseTimeout returns the period after which the persistent
+// volume claims of the specified namespace are released if the node holding
+// them is gone.
+func getLocalVolumeReleaseTimeout(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (time.Duration, error) {
+	timeout := defaultLocalVolumeReleaseTimeout
+	if namespace.Storage.LocalVolumeReleaseTimeout != nil {
+		timeout = *namespace.Storage.LocalVolumeReleaseTimeout
+	}
+	return astime.ParseDuration(timeout)
+}
@@ -0,0 +1,401 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+const (
+	// the kind of the servicemonitor resources of the prometheus operator
+	serviceMonitorKind = "ServiceMonitor"
+	// the kind of the prometheusrule resources of the prometheus operator
+	prometheusRuleKind = "PrometheusRule"
+
+	// the value of the severity label of alerts requiring immediate action
+	alertSeverityCritical = "critical"
+	// the value of the severity label of alerts requiring attention
+	alertSeverityWarning = "warning"
+	// the period during which the stop-writes, high-water mark and node down
+	// conditions must hold before the corresponding alerts fire
+	alertPendingPeriod = "5m"
+)
+
+var (
+	// monitoringGroupVersion is the api group and version of the resources
+	// of the prometheus operator
+	monitoringGroupVersion = schema.GroupVersion{Group: "monitoring.coreos.com", Version: "v1"}
+	// serviceMonitorsResource identifies servicemonitor resources
+	serviceMonitorsResource = monitoringGroupVersion.WithResource("servicemonitors")
+	// prometheusRulesResource identifies prometheusrule resources
+	prometheusRulesResource = monitoringGroupVersion.WithResource("prometheusrules")
+)
+
+// monitoringResourcesCache holds the set of (plural) names of the resources of
+// the prometheus operator which are served by the api server, so that
+// discovery is not performed on every reconcile.
+type monitoringResourcesCache struct {
+	sync.Mutex
+	// the set of names of the resources served by the api server
+	resources map[string]bool
+	// the time at which resources was last refreshed
+	refreshTime time.Time
+}
+
+// getExporterImage returns the image to use for the asprom container of the
+// pods of aerospikeCluster.
+func getExporterImage(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	if m := aerospikeCluster.Spec.Monitoring; m != nil && m.Exporter != nil && m.Exporter.Image != "" {
+		return m.Exporter.Image
+	}
+	return images.ToolsImage(aerospikeCluster.Spec.Image)
+}
+
+// getExporterResources returns the resource requests and limits to use for the
+// asprom container of the pods of aerospikeCluster.
+func getExporterResources(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) corev1.ResourceRequirements {
+	if m := aerospikeCluster.Spec.Monitoring; m != nil && m.Exporter != nil && m.Exporter.Resources != nil {
+		return *m.Exporter.Resources
+	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(aspromDefaultCpuRequest),
+			corev1.ResourceMemory: resource.MustParse(aspromDefaultMemoryRequest),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(aspromDefaultCpuLimit),
+			corev1.ResourceMemory: resource.MustParse(aspromDefaultMemoryLimit),
+		},
+	}
+}
+
+// ensureMonitoringResources creates, updates or deletes the servicemonitor and
+// prometheusrule resources of aerospikeCluster according to its spec.
+func (r *AerospikeClusterReconciler) ensureMonitoringResources(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	var serviceMonitor, prometheusRule *unstructured.Unstructured
+	if m := aerospikeCluster.Spec.Monitoring; m != nil {
+		if m.ServiceMonitor != nil {
+			serviceMonitor = buildServiceMonitor(aerospikeCluster)
+		}
+		if m.PrometheusRule != nil {
+			prometheusRule = buildPrometheusRule(aerospikeCluster)
+		}
+	}
+
+	// check which of the resources of the prometheus operator are available
+	available, err := r.getAvailableMonitoringResources()
+	if err != nil {
+		return err
+	}
+	for _, item := range []struct {
+		resource schema.GroupVersionResource
+		kind     string
+		desired  *unstructured.Unstructured
+	}{
+		{serviceMonitorsResource, serviceMonitorKind, serviceMonitor},
+		{prometheusRulesResource, prometheusRuleKind, prometheusRule},
+	} {
+		if !available[item.resource.Resource] {
+			// there is nothing to delete if the resource is not available
+			if item.desired == nil {
+				continue
+			}
+			r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonMonitoringResourceUnavailable,
+				"cannot create %s as %s is not available", item.kind, item.resource.GroupResource().String())
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			}).Warnf("cannot create %s as %s is not available", item.kind, item.resource.GroupResource().String())
+			continue
+		}
+		if item.desired == nil {
+			if err := r.deleteMonitoringResource(aerospikeCluster, item.resource, item.kind); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := r.ensureMonitoringResource(aerospikeCluster, item.resource, item.kind, item.desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getAvailableMonitoringResources returns the set of (plural) names of the
+// resources of the prometheus operator which are served by the api server. The
+// result of discovery is cached for monitoringResourcesRefreshInterval.
+func (r *AerospikeClusterReconciler) getAvailableMonitoringResources() (map[string]bool, error) {
+	r.monitoringResources.Lock()
+	defer r.monitoringResources.Unlock()
+	if r.monitoringResources.resources != nil && time.Since(r.monitoringResources.refreshTime) < monitoringResourcesRefreshInterval {
+		return r.monitoringResources.resources, nil
+	}
+
+	res := make(map[string]bool)
+	list, err := r.kubeclientset.Discovery().ServerResourcesForGroupVersion(monitoringGroupVersion.String())
+	if err != nil {
+		// the prometheus operator is not installed
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+	} else {
+		for _, resource := range list.APIResources {
+			res[resource.Name] = true
+		}
+	}
+	r.monitoringResources.resources = res
+	r.monitoringResources.refreshTime = time.Now()
+	return res, nil
+}
+
+// ensureMonitoringResource creates the specified resource of the prometheus
+// operator, or updates it if it already exists and differs from desired.
+// Resources which are not controlled by aerospikeCluster are left untouched.
+func (r *AerospikeClusterReconciler) ensureMonitoringResource(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, gvr schema.GroupVersionResource, kind string, desired *unstructured.Unstructured) error {
+	client := r.dynamicclientset.Resource(gvr).Namespace(aerospikeCluster.Namespace)
+
+	current, err := client.Get(desired.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		if _, err := client.Create(desired, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debugf("%s created", kind)
+		return nil
+	}
+
+	// a resource with the same name already exists, which we only update if it
+	// is controlled by aerospikeCluster
+	if !metav1.IsControlledBy(current, aerospikeCluster) {
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonMonitoringResourceConflict,
+			"cannot create %s as one with the same name which is not controlled by the cluster already exists", kind)
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Warnf("cannot create %s as one with the same name which is not controlled by the cluster already exists", kind)
+		return nil
+	}
+	if reflect.DeepEqual(current.GetLabels(), desired.GetLabels()) && reflect.DeepEqual(current.Object["spec"], desired.Object["spec"]) {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debugf("%s exists and is up to date", kind)
+		return nil
+	}
+	current.SetLabels(desired.GetLabels())
+	current.Object["spec"] = desired.Object["spec"]
+	if _, err := client.Update(current, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debugf("%s updated", kind)
+	return nil
+}
+
+// deleteMonitoringResource deletes the specified resource of the prometheus
+// operator, if it exists and is controlled by aerospikeCluster.
+func (r *AerospikeClusterReconciler) deleteMonitoringResource(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, gvr schema.GroupVersionResource, kind string) error {
+	client := r.dynamicclientset.Resource(gvr).Namespace(aerospikeCluster.Namespace)
+
+	current, err := client.Get(aerospikeCluster.Name, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(current, aerospikeCluster) {
+		return nil
+	}
+	uid := current.GetUID()
+	if err := client.Delete(current.GetName(), &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			UID: &uid,
+		},
+	}); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debugf("%s deleted", kind)
+	return nil
+}
+
+// newMonitoringResource returns a resource of the prometheus operator of the
+// specified kind which is owned by aerospikeCluster and has the specified
+// additional labels.
+func newMonitoringResource(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, kind string, extraLabels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(monitoringGroupVersion.String())
+	obj.SetKind(kind)
+	obj.SetName(aerospikeCluster.Name)
+	obj.SetNamespace(aerospikeCluster.Namespace)
+
+	// the labels used to identify the resources of the cluster always take
+	// precedence
+	labels := make(map[string]string, len(extraLabels)+2)
+	for key, value := range extraLabels {
+		labels[key] = value
+	}
+	labels[selectors.LabelAppKey] = selectors.LabelAppVal
+	labels[selectors.LabelClusterKey] = aerospikeCluster.Name
+	obj.SetLabels(labels)
+
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion:         aerospikev1alpha2.SchemeGroupVersion.String(),
+			Kind:               crd.AerospikeClusterKind,
+			Name:               aerospikeCluster.Name,
+			UID:                aerospikeCluster.UID,
+			Controller:         pointers.NewBool(true),
+			BlockOwnerDeletion: pointers.NewBool(true),
+		},
+	})
+	return obj
+}
+
+// buildServiceMonitor returns the servicemonitor resource used to scrape the
+// asprom containers of aerospikeCluster through its headless service.
+func buildServiceMonitor(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *unstructured.Unstructured {
+	spec := aerospikeCluster.Spec.Monitoring.ServiceMonitor
+	obj := newMonitoringResource(aerospikeCluster, serviceMonitorKind, spec.Labels)
+
+	endpoint := map[string]interface{}{
+		"port": aspromPortName,
+		"path": aspromPath,
+	}
+	if spec.Interval != "" {
+		endpoint["interval"] = spec.Interval
+	}
+	if spec.ScrapeTimeout != "" {
+		endpoint["scrapeTimeout"] = spec.ScrapeTimeout
+	}
+	obj.Object["spec"] = map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				selectors.LabelAppKey:     selectors.LabelAppVal,
+				selectors.LabelClusterKey: aerospikeCluster.Name,
+			},
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{
+				aerospikeCluster.Namespace,
+			},
+		},
+		"endpoints": []interface{}{
+			endpoint,
+		},
+	}
+	return obj
+}
+
+// buildPrometheusRule returns the prometheusrule resource holding the alerting
+// rules for aerospikeCluster. The rules match the metrics exported by asprom
+// as scraped through the servicemonitor of aerospikeCluster.
+func buildPrometheusRule(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *unstructured.Unstructured {
+	spec := aerospikeCluster.Spec.Monitoring.PrometheusRule
+	obj := newMonitoringResource(aerospikeCluster, prometheusRuleKind, spec.Labels)
+
+	migrationsStuckAfter := defaultMigrationsStuckAfter
+	if spec.MigrationsStuckAfter != nil {
+		migrationsStuckAfter = *spec.MigrationsStuckAfter
+	}
+	// the labels selecting the series of the current cluster, as set by the
+	// prometheus operator on the targets of the servicemonitor
+	sel := fmt.Sprintf(`namespace=%q,service=%q`, aerospikeCluster.Namespace, aerospikeCluster.Name)
+
+	rules := []interface{}{
+		newAlertingRule(
+			"AerospikeStopWrites",
+			fmt.Sprintf(`aerospike_ns_stop_writes{%s} > 0`, sel),
+			alertPendingPeriod,
+			alertSeverityCritical,
+			"Aerospike node {{ $labels.pod }} is refusing writes to namespace {{ $labels.exported_namespace }}.",
+		),
+		newAlertingRule(
+			"AerospikeHighWaterMarkBreached",
+			fmt.Sprintf(`aerospike_ns_hwm_breached{%s} > 0`, sel),
+			alertPendingPeriod,
+			alertSeverityWarning,
+			"Aerospike node {{ $labels.pod }} has breached the high-water mark of namespace {{ $labels.exported_namespace }} and is evicting data.",
+		),
+		newAlertingRule(
+			"AerospikeNodeDown",
+			fmt.Sprintf(`up{%[1]s} == 0 or aerospike_node_up{%[1]s} == 0 or aerospike_node_cluster_size{%[1]s} < %[2]d`, sel, aerospikeCluster.Spec.NodeCount),
+			alertPendingPeriod,
+			alertSeverityCritical,
+			"Aerospike node {{ $labels.pod }} is down or does not see every node in the cluster.",
+		),
+		newAlertingRule(
+			"AerospikeMigrationsStuck",
+			fmt.Sprintf(`aerospike_ns_migrate_tx_partitions_remaining{%[1]s} > 0 or aerospike_ns_migrate_rx_partitions_remaining{%[1]s} > 0`, sel),
+			migrationsStuckAfter,
+			alertSeverityWarning,
+			fmt.Sprintf("Migrations of namespace {{ $labels.exported_namespace }} on Aerospike node {{ $labels.pod }} have been in progress for more than %s.", migrationsStuckAfter),
+		),
+	}
+	obj.Object["spec"] = map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{
+				"name":  fmt.Sprintf("aerospike-%s-%s", aerospikeCluster.Namespace, aerospikeCluster.Name),
+				"rules": rules,
+			},
+		},
+	}
+	return obj
+}
+
+// newAlertingRule returns an alerting rule in the format used by prometheusrule
+// resources.
+func newAlertingRule(name, expr, period, severity, description string) map[string]interface{} {
+	return map[string]interface{}{
+		"alert": name,
+		"expr":  expr,
+		"for":   period,
+		"labels": map[string]interface{}{
+			"severity": severity,
+		},
+		"annotations": map[string]interface{}{
+			"description": description,
+		},
+	}
+}
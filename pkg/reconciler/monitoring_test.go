@@ -0,0 +1,153 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+)
+
+func TestBuildMonitoringResources(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	aerospikeCluster.Spec.Monitoring = &aerospikev1alpha2.MonitoringSpec{
+		ServiceMonitor: &aerospikev1alpha2.ServiceMonitorSpec{
+			Labels:        map[string]string{"prometheus": "aerospike", "app": "ignored"},
+			Interval:      "30s",
+			ScrapeTimeout: "10s",
+		},
+		PrometheusRule: &aerospikev1alpha2.PrometheusRuleSpec{
+			Labels:               map[string]string{"prometheus": "aerospike"},
+			MigrationsStuckAfter: pointers.NewString("1h"),
+		},
+	}
+
+	tests := []struct {
+		obj    *unstructured.Unstructured
+		golden string
+	}{
+		{buildServiceMonitor(aerospikeCluster), "servicemonitor.yaml"},
+		{buildPrometheusRule(aerospikeCluster), "prometheusrule.yaml"},
+	}
+	for _, test := range tests {
+		// the resources must only hold json-compatible values
+		assert.NotPanics(t, func() { test.obj.DeepCopy() }, test.golden)
+		b, err := yaml.Marshal(test.obj.Object)
+		assert.NoError(t, err)
+		path := filepath.Join("testdata", test.golden)
+		if *update {
+			assert.NoError(t, ioutil.WriteFile(path, b, 0644))
+		}
+		expected, err := ioutil.ReadFile(path)
+		assert.NoError(t, err)
+		assert.Equal(t, string(expected), string(b), test.golden)
+	}
+}
+
+func TestExporterSpec(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	aerospikeCluster.Spec.PodSpec = &aerospikev1alpha2.AerospikePodSpec{
+		PriorityClassName: "high-priority",
+	}
+
+	// the pod spec hash of clusters not customizing asprom must not change
+	b, err := json.Marshal(aerospikeCluster.Spec.PodSpec)
+	assert.NoError(t, err)
+	hash, err := computePodSpecHash(aerospikeCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, asstrings.Hash(string(b)), hash)
+	assert.Equal(t, resource.MustParse(aspromDefaultCpuLimit), getExporterResources(aerospikeCluster).Limits[corev1.ResourceCPU])
+
+	// customizing asprom changes the pod spec hash so that pods are restarted
+	resources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("100m"),
+		},
+	}
+	aerospikeCluster.Spec.Monitoring = &aerospikev1alpha2.MonitoringSpec{
+		Exporter: &aerospikev1alpha2.ExporterSpec{
+			Image:     "registry.example.com/asprom:1.0.0",
+			Resources: &resources,
+		},
+	}
+	newHash, err := computePodSpecHash(aerospikeCluster)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, newHash)
+	assert.Equal(t, "registry.example.com/asprom:1.0.0", getExporterImage(aerospikeCluster))
+	assert.Equal(t, resources, getExporterResources(aerospikeCluster))
+}
+
+func TestMonitoringResourceOwnership(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	aerospikeCluster.UID = types.UID("cluster-uid")
+	aerospikeCluster.Spec.Monitoring = &aerospikev1alpha2.MonitoringSpec{
+		ServiceMonitor: &aerospikev1alpha2.ServiceMonitorSpec{
+			Interval:      "30s",
+			ScrapeTimeout: "10s",
+		},
+	}
+	desired := buildServiceMonitor(aerospikeCluster)
+
+	tests := []struct {
+		name       string
+		controlled bool
+	}{
+		{"controlled by the cluster", true},
+		{"not controlled by the cluster", false},
+	}
+	for _, test := range tests {
+		current := desired.DeepCopy()
+		current.Object["spec"] = map[string]interface{}{"jobLabel": "other"}
+		if !test.controlled {
+			current.SetOwnerReferences(nil)
+		}
+		r := &AerospikeClusterReconciler{
+			dynamicclientset: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), current),
+			recorder:         record.NewFakeRecorder(10),
+		}
+		client := r.dynamicclientset.Resource(serviceMonitorsResource).Namespace(aerospikeCluster.Namespace)
+
+		// the resource is only updated if it is controlled by the cluster
+		assert.NoError(t, r.ensureMonitoringResource(aerospikeCluster, serviceMonitorsResource, serviceMonitorKind, desired), test.name)
+		obj, err := client.Get(aerospikeCluster.Name, metav1.GetOptions{})
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.controlled, reflect.DeepEqual(desired.Object["spec"], obj.Object["spec"]), test.name)
+
+		// the resource is only deleted if it is controlled by the cluster
+		assert.NoError(t, r.deleteMonitoringResource(aerospikeCluster, serviceMonitorsResource, serviceMonitorKind), test.name)
+		_, err = client.Get(aerospikeCluster.Name, metav1.GetOptions{})
+		assert.Equal(t, test.controlled, errors.IsNotFound(err), test.name)
+	}
+}
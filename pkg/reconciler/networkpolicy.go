@@ -17,6 +17,8 @@ limitations under the License.
 package reconciler
 
 import (
+	"reflect"
+
 	log "github.com/sirupsen/logrus"
 	"k8s.io/api/core/v1"
 	networkv1 "k8s.io/api/networking/v1"
@@ -166,18 +168,56 @@ func (r *AerospikeClusterReconciler) ensureNetworkPolicy(aerospikeCluster *aeros
 		},
 	}
 
+	// allow for shipping records to remote datacenters if cross-datacenter
+	// replication is enabled
+	if ports := getXDRDestinationPorts(aerospikeCluster); len(ports) > 0 {
+		rule := networkv1.NetworkPolicyEgressRule{}
+		for _, port := range ports {
+			rule.Ports = append(rule.Ports, networkv1.NetworkPolicyPort{
+				Protocol: &protocolTCP,
+				Port: &intstr.IntOrString{
+					IntVal: port,
+				},
+			})
+		}
+		policy.Spec.Egress = append(policy.Spec.Egress, rule)
+	}
+
 	if _, err := r.kubeclientset.NetworkingV1().NetworkPolicies(aerospikeCluster.Namespace).Create(&policy); err != nil {
 		if !errors.IsAlreadyExists(err) {
 			return err
 		}
+		// a networkpolicy with the same name already exists, so we need to
+		// handle an update
+		return r.updateNetworkPolicy(aerospikeCluster, &policy)
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("networkpolicy created")
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) updateNetworkPolicy(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desiredPolicy *networkv1.NetworkPolicy) error {
+	// get the current networkpolicy resource
+	currentPolicy, err := r.kubeclientset.NetworkingV1().NetworkPolicies(aerospikeCluster.Namespace).Get(desiredPolicy.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	// if the networkpolicy is up-to-date, we're good to go
+	if reflect.DeepEqual(currentPolicy.Spec, desiredPolicy.Spec) {
 		log.WithFields(log.Fields{
 			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-		}).Debug("networkpolicy already exists")
+		}).Debug("networkpolicy exists and is up to date")
 		return nil
 	}
-
+	// update the existing networkpolicy resource to match the desired state
+	currentPolicy.Spec = desiredPolicy.Spec
+	if _, err := r.kubeclientset.NetworkingV1().NetworkPolicies(aerospikeCluster.Namespace).Update(currentPolicy); err != nil {
+		return err
+	}
 	log.WithFields(log.Fields{
 		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-	}).Debug("networkpolicy created")
+	}).Debug("networkpolicy updated")
 	return nil
 }
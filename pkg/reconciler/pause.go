@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+)
+
+// UpdatePausedStatus updates the status of an aerospikecluster whose
+// reconciliation is paused. Only the observed state of the cluster is updated,
+// as its spec is not being applied, and the ReconciliationPaused condition is
+// set.
+func (r *AerospikeClusterReconciler) UpdatePausedStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+	// check whether reconciliation has just been paused
+	c := getCondition(aerospikeCluster, common.ConditionReconciliationPaused)
+	justPaused := c == nil || c.Status != apiextensions.ConditionTrue
+
+	r.updateXDRStatus(aerospikeCluster)
+	r.updateObservedStatus(aerospikeCluster)
+	r.updateClusterConditions(aerospikeCluster, nil)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionReconciliationPaused,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             events.ReasonReconciliationPaused,
+		Message:            "reconciliation is paused",
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	if justPaused {
+		r.recorder.Event(aerospikeCluster, corev1.EventTypeNormal, events.ReasonReconciliationPaused,
+			"reconciliation paused")
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Info("reconciliation paused")
+	}
+	return nil
+}
+
+// signalReconciliationResumed sets the ReconciliationPaused condition to False
+// in aerospikeCluster if reconciliation was previously paused.
+func (r *AerospikeClusterReconciler) signalReconciliationResumed(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// there is nothing to do unless reconciliation was paused
+	if c := getCondition(aerospikeCluster, common.ConditionReconciliationPaused); c == nil || c.Status != apiextensions.ConditionTrue {
+		return nil
+	}
+
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionReconciliationPaused,
+		Status:             apiextensions.ConditionFalse,
+		Reason:             events.ReasonReconciliationResumed,
+		Message:            "reconciliation is active",
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Event(aerospikeCluster, corev1.EventTypeNormal, events.ReasonReconciliationResumed,
+		"reconciliation resumed")
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Info("reconciliation resumed")
+
+	return nil
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"reflect"
+
+	log "github.com/sirupsen/logrus"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/crd"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/selectors"
+)
+
+func (r *AerospikeClusterReconciler) ensurePodDisruptionBudget(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	minAvailable := intstr.FromInt(int(computeMinAvailable(aerospikeCluster)))
+	pdb := policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: aerospikeCluster.Name,
+			Labels: map[string]string{
+				selectors.LabelAppKey:     selectors.LabelAppVal,
+				selectors.LabelClusterKey: aerospikeCluster.Name,
+			},
+			Namespace: aerospikeCluster.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         aerospikev1alpha2.SchemeGroupVersion.String(),
+					Kind:               crd.AerospikeClusterKind,
+					Name:               aerospikeCluster.Name,
+					UID:                aerospikeCluster.UID,
+					Controller:         pointers.NewBool(true),
+					BlockOwnerDeletion: pointers.NewBool(true),
+				},
+			},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MinAvailable: &minAvailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					selectors.LabelAppKey:     selectors.LabelAppVal,
+					selectors.LabelClusterKey: aerospikeCluster.Name,
+				},
+			},
+		},
+	}
+
+	if _, err := r.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(aerospikeCluster.Namespace).Create(&pdb); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
+		// a poddisruptionbudget with the same name already exists, so we need
+		// to handle an update
+		return r.updatePodDisruptionBudget(aerospikeCluster, &pdb)
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("poddisruptionbudget created")
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) updatePodDisruptionBudget(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, desiredPDB *policyv1beta1.PodDisruptionBudget) error {
+	// get the current poddisruptionbudget resource
+	currentPDB, err := r.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(aerospikeCluster.Namespace).Get(desiredPDB.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	// if the poddisruptionbudget is up-to-date, we're good to go
+	if reflect.DeepEqual(currentPDB.Spec, desiredPDB.Spec) {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debug("poddisruptionbudget exists and is up to date")
+		return nil
+	}
+	// the spec of a poddisruptionbudget is immutable before kubernetes 1.15,
+	// so we must delete and re-create it
+	if err := r.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(aerospikeCluster.Namespace).Delete(currentPDB.Name, &metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{
+			UID: &currentPDB.UID,
+		},
+	}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if _, err := r.kubeclientset.PolicyV1beta1().PodDisruptionBudgets(aerospikeCluster.Namespace).Create(desiredPDB); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("poddisruptionbudget updated")
+	return nil
+}
+
+// computeMinAvailable returns the minimum number of pods that must be available
+// at any time in order for every aerospike namespace to remain fully available.
+// At most one less than the lowest replication factor across namespaces can be
+// unavailable. In particular, if any namespace keeps a single copy of its data
+// no pod can be evicted, and node drains require the pods to be deleted
+// manually.
+func computeMinAvailable(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) int32 {
+	minReplicationFactor := aerospikeCluster.Spec.NodeCount
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		replicationFactor := getReplicationFactor(&ns)
+		if replicationFactor < minReplicationFactor {
+			minReplicationFactor = replicationFactor
+		}
+	}
+	maxUnavailable := minReplicationFactor - 1
+	if maxUnavailable < 0 {
+		maxUnavailable = 0
+	}
+	if aerospikeCluster.Spec.NodeCount < maxUnavailable {
+		return 0
+	}
+	return aerospikeCluster.Spec.NodeCount - maxUnavailable
+}
+
+// getReplicationFactor returns the replication factor of the specified
+// namespace, or the default replication factor if none is specified.
+func getReplicationFactor(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) int32 {
+	if namespace.ReplicationFactor != nil {
+		return *namespace.ReplicationFactor
+	}
+	return common.DefaultReplicationFactor
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+func TestComputeMinAvailable(t *testing.T) {
+	tests := []struct {
+		name               string
+		nodeCount          int32
+		replicationFactors []*int32
+		expected           int32
+	}{
+		{"single node", 1, []*int32{pointers.NewInt32(1)}, 1},
+		{"replication factor of 1", 3, []*int32{pointers.NewInt32(1)}, 3},
+		{"default replication factor", 3, []*int32{nil}, 2},
+		{"replication factor of 2", 3, []*int32{pointers.NewInt32(2)}, 2},
+		{"replication factor of 3", 5, []*int32{pointers.NewInt32(3)}, 3},
+		{"replication factor equal to node count", 3, []*int32{pointers.NewInt32(3)}, 1},
+		{"lowest replication factor across namespaces", 5, []*int32{pointers.NewInt32(3), pointers.NewInt32(1)}, 5},
+	}
+	for _, test := range tests {
+		aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+			Spec: aerospikev1alpha2.AerospikeClusterSpec{
+				NodeCount: test.nodeCount,
+			},
+		}
+		for _, replicationFactor := range test.replicationFactors {
+			aerospikeCluster.Spec.Namespaces = append(aerospikeCluster.Spec.Namespaces, aerospikev1alpha2.AerospikeNamespaceSpec{
+				ReplicationFactor: replicationFactor,
+			})
+		}
+		assert.Equal(t, test.expected, computeMinAvailable(aerospikeCluster), test.name)
+	}
+}
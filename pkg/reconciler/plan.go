@@ -0,0 +1,121 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"reflect"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+// planUpgrade computes the plan for upgrading aerospikeCluster to the version
+// requested in its spec and records it in its status. If the requested version
+// cannot be reached in a single hop, the version in the spec of
+// aerospikeCluster is replaced (in memory only) with the target version of the
+// current hop, so that the remainder of the reconcile loop (including the
+// pre-upgrade backup) deals with the current hop only.
+func (r *AerospikeClusterReconciler) planUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	var plan versioning.UpgradePlan
+	if aerospikeCluster.Status.Version != "" && aerospikeCluster.Spec.Version != aerospikeCluster.Status.Version {
+		// parse the source version
+		source, err := versioning.NewVersionFromString(aerospikeCluster.Status.Version)
+		if err != nil {
+			return err
+		}
+		// parse the target version
+		target, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+		if err != nil {
+			return err
+		}
+		if plan, err = versioning.PlanUpgrade(source, target); err != nil {
+			return err
+		}
+	}
+
+	planned := updateUpgradePlanStatus(aerospikeCluster, plan)
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	if planned {
+		versions := strings.Join(aerospikeCluster.Status.UpgradePlan.Versions, ", ")
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonUpgradePlanned,
+			"upgrading through versions %s", versions)
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Infof("upgrading through versions %s", versions)
+	}
+
+	// perform the first hop of the remaining plan
+	if len(plan) > 1 {
+		aerospikeCluster.Spec.Version = plan[0].Target.String()
+	}
+	return nil
+}
+
+// getRequestedVersion returns the version requested by the user for
+// aerospikeCluster. The version in the spec may have been replaced with the
+// target version of the current hop of a multi-hop upgrade, so the requested
+// version is taken from the upgrade plan (if any).
+func getRequestedVersion(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	plan := aerospikeCluster.Status.UpgradePlan
+	if plan != nil && len(plan.Versions) > 0 {
+		return plan.Versions[len(plan.Versions)-1]
+	}
+	return aerospikeCluster.Spec.Version
+}
+
+// updateUpgradePlanStatus records the specified plan in the status of
+// aerospikeCluster. The versions of the plan being carried out are kept as
+// long as the remaining plan follows them, so that the hops already performed
+// are still reported. It returns whether a new plan has been recorded.
+func updateUpgradePlanStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, plan versioning.UpgradePlan) bool {
+	status := aerospikeCluster.Status.UpgradePlan
+	versions := plan.Versions()
+
+	// check whether the remaining plan is the tail of the plan being carried out
+	if status != nil && len(versions) > 0 {
+		for i := range status.Versions {
+			if reflect.DeepEqual(status.Versions[i:], versions) {
+				status.CurrentHop = int32(i + 1)
+				return false
+			}
+		}
+	}
+	// upgrades performed in a single hop require no plan
+	if len(plan) <= 1 {
+		aerospikeCluster.Status.UpgradePlan = nil
+		return false
+	}
+	aerospikeCluster.Status.UpgradePlan = &aerospikev1alpha2.UpgradePlanStatus{
+		Versions:   versions,
+		CurrentHop: 1,
+	}
+	return true
+}
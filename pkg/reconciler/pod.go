@@ -41,6 +41,7 @@ import (
 	"github.com/travelaudience/aerospike-operator/pkg/asutils"
 	"github.com/travelaudience/aerospike-operator/pkg/crd"
 	"github.com/travelaudience/aerospike-operator/pkg/debug"
+	"github.com/travelaudience/aerospike-operator/pkg/images"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
 	"github.com/travelaudience/aerospike-operator/pkg/pointers"
@@ -72,6 +73,17 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 		logfields.DesiredSize:      desiredSize,
 	}).Debug("checking if pods need to be updated")
 
+	// check whether the remaining pods can absorb the data held by the cluster
+	// before scaling down, and keep every existing pod otherwise so that they
+	// are still reconciled
+	scaleDownAllowed, err := r.validateScaleDown(aerospikeCluster, pods)
+	if err != nil {
+		return err
+	}
+	if !scaleDownAllowed {
+		desiredSize = currentSize
+	}
+
 	// scale down if necessary
 	for i := currentSize - 1; i >= desiredSize; i-- {
 		if err := r.safeDeletePodWithIndex(aerospikeCluster, i); err != nil {
@@ -82,6 +94,17 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 		}
 	}
 
+	// compute the hash of the pod customizations so we can detect pods that
+	// must be restarted
+	podSpecHash, err := computePodSpecHash(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+
+	// restarts will contain the indexes of the pods which must be restarted
+	// according to the cluster's rollout strategy
+	var restarts []int
+
 	// create/upgrade/restart existing pods as required
 	for i := 0; i < desiredSize; i++ {
 		// attempt to grab the pod with the specified index
@@ -109,6 +132,29 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 			pod = nil
 		}
 
+		// check whether the pod cannot be scheduled because the node holding
+		// its local persistent volumes is gone, in which case it is deleted and
+		// later re-created with new persistent volume claims
+		var releasedPVCs bool
+		if pod != nil {
+			if releasedPVCs, err = r.maybeReleaseLocalPersistentVolumeClaims(aerospikeCluster, pod); err != nil {
+				return err
+			}
+			if releasedPVCs {
+				// set pod to nil since we have just deleted it
+				pod = nil
+			}
+		}
+
+		// check whether the pod must be restarted with new persistent volume
+		// claims because its current ones do not match the storage spec
+		var replacePVCs bool
+		if pod != nil {
+			if replacePVCs, err = r.podRequiresNewPersistentVolumeClaims(aerospikeCluster, pod); err != nil {
+				return err
+			}
+		}
+
 		switch {
 		// check whether the pod needs to be created
 		case pod == nil:
@@ -121,8 +167,12 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 				}).Errorf("failed to create pod: %v", err)
 				return err
 			}
-		// check whether the pod needs to be upgraded
+		// check whether the pod needs to be upgraded, holding the upgrade
+		// while the health of the canary pods is checked if requested
 		case upgrade != nil:
+			if err := r.maybeSoakCanaryPods(aerospikeCluster, i, pod, upgrade); err != nil {
+				return err
+			}
 			pod, err = r.maybeUpgradePodWithIndex(aerospikeCluster, configMap, i, upgrade)
 			if err != nil {
 				log.WithFields(log.Fields{
@@ -131,8 +181,16 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 				}).Errorf("failed to upgrade pod: %v", err)
 				return err
 			}
-		// check whether the pod needs to be restarted
-		case configMap.Annotations[configMapHashAnnotation] != pod.Annotations[configMapHashAnnotation]:
+		// check whether the pod needs to be restarted with new persistent
+		// volume claims, in which case it will start empty and have its data
+		// migrated back from the remaining nodes
+		case replacePVCs:
+			if err := r.signalStorageUpdateInProgress(aerospikeCluster, fmt.Sprintf("replacing the persistentvolumeclaims of pod %s", meta.Key(pod))); err != nil {
+				return err
+			}
+			if err := r.markPersistentVolumeClaimsReplaced(aerospikeCluster, pod); err != nil {
+				return err
+			}
 			pod, err = r.safeRestartPodWithIndex(aerospikeCluster, configMap, i, upgrade)
 			if err != nil {
 				log.WithFields(log.Fields{
@@ -141,12 +199,37 @@ func (r *AerospikeClusterReconciler) ensurePods(aerospikeCluster *aerospikev1alp
 				}).Errorf("failed to restart pod: %v", err)
 				return err
 			}
+		// check whether the pod needs to be restarted because either the
+		// configuration, the pod customizations or the aerospike server image
+		// have changed, in which case it is restarted after every other pod has
+		// been handled (see restartPods)
+		case configMap.Annotations[configMapHashAnnotation] != pod.Annotations[configMapHashAnnotation],
+			podSpecHash != pod.Annotations[podSpecHashAnnotation],
+			pod.Spec.Containers[0].Image != images.ServerImage(aerospikeCluster.Spec.Image, aerospikeCluster.Spec.Version):
+			restarts = append(restarts, i)
+			continue
 		}
 
 		// ensure aerospike is reachable and reports the correct clusterSize
 		if err := r.ensureClusterSize(aerospikeCluster, pod); err != nil {
 			return err
 		}
+
+		// if the pod has been restarted with new persistent volume claims, wait
+		// for its data to be migrated back before moving on to the next pod
+		if (replacePVCs || releasedPVCs) && upgrade == nil {
+			if err := r.waitForMigrationsToFinish(aerospikeCluster, pod); err != nil {
+				return err
+			}
+			r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonPersistentVolumeClaimsReplaced,
+				"persistentvolumeclaims of pod %s replaced", meta.Key(pod))
+		}
+	}
+
+	// restart the pods whose configuration, customizations or image have
+	// changed according to the cluster's rollout strategy
+	if err := r.restartPods(aerospikeCluster, configMap, restarts, upgrade); err != nil {
+		return err
 	}
 
 	// signal that we're good and return
@@ -179,10 +262,10 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 	finalConfigFilePath := path.Join(finalConfigMountPath, configFileName)
 	// podName contains the name of the pod
 	podName := fmt.Sprintf("%s-%d", aerospikeCluster.Name, index)
-	// nodeId will contain the value used as service.node-id for the pod
-	nodeId, err := computeNodeId(podName)
+	// podSpecHash will contain the hash of the pod customizations
+	podSpecHash, err := computePodSpecHash(aerospikeCluster)
 	if err != nil {
-		return nil, fmt.Errorf("failed to compute node id for %s: %v", podName, err)
+		return nil, err
 	}
 
 	// list all active pods so we can use those as mesh seeds for the pod
@@ -224,21 +307,21 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 			},
 			Annotations: map[string]string{
 				configMapHashAnnotation: configMap.Annotations[configMapHashAnnotation],
-				nodeIdAnnotation:        nodeId,
+				podSpecHashAnnotation:   podSpecHash,
 			},
 		},
 		Spec: corev1.PodSpec{
 			// use a init container to set the values of service.node-id to the
-			// value of nodeId and of network.heartbeat.mesh-seed-adress-port[]
-			// to the list of currently active nodes
+			// value of nodeId (see below) and of
+			// network.heartbeat.mesh-seed-adress-port[] to the list of currently
+			// active nodes
 			InitContainers: []corev1.Container{
 				{
-					Name:  "init",
-					Image: fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
+					Name:            initContainerName,
+					Image:           images.ToolsImage(aerospikeCluster.Spec.Image),
+					ImagePullPolicy: images.GetPullPolicy(aerospikeCluster.Spec.Image, ""),
 					Command: []string{
 						"/usr/local/bin/asinit",
-						"--node-id",
-						nodeId,
 						"--peer-list",
 						peerList,
 						"--source-config",
@@ -266,8 +349,9 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 			},
 			Containers: []corev1.Container{
 				{
-					Name:  "aerospike-server",
-					Image: fmt.Sprintf("aerospike/aerospike-server:%s", aerospikeCluster.Spec.Version),
+					Name:            serverContainerName,
+					Image:           images.ServerImage(aerospikeCluster.Spec.Image, aerospikeCluster.Spec.Version),
+					ImagePullPolicy: images.GetPullPolicy(aerospikeCluster.Spec.Image, ""),
 					Command: []string{
 						"/usr/bin/asd",
 						"--foreground",
@@ -320,9 +404,9 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 					},
 				},
 				{
-					Name:            "asprom",
-					Image:           fmt.Sprintf("%s:%s", "quay.io/travelaudience/aerospike-operator-tools", versioning.OperatorVersion),
-					ImagePullPolicy: corev1.PullAlways,
+					Name:            aspromContainerName,
+					Image:           getExporterImage(aerospikeCluster),
+					ImagePullPolicy: images.GetPullPolicy(aerospikeCluster.Spec.Image, corev1.PullAlways),
 					Command: []string{
 						"asprom",
 					},
@@ -335,23 +419,14 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 					LivenessProbe: &corev1.Probe{
 						Handler: corev1.Handler{
 							HTTPGet: &corev1.HTTPGetAction{
-								Path: "/metrics",
+								Path: aspromPath,
 								Port: intstr.IntOrString{
 									IntVal: aspromPort,
 								},
 							},
 						},
 					},
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse(aspromCpuRequest),
-							corev1.ResourceMemory: resource.MustParse(aspromMemoryRequest),
-						},
-						Limits: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse(aspromCpuLimit),
-							corev1.ResourceMemory: resource.MustParse(aspromMemoryLimit),
-						},
-					},
+					Resources: getExporterResources(aerospikeCluster),
 				},
 			},
 			Volumes: []corev1.Volume{
@@ -372,6 +447,8 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 					},
 				},
 			},
+			// use the configured secrets for pulling images
+			ImagePullSecrets: images.GetPullSecrets(aerospikeCluster.Spec.Image),
 			// let the reconcile loop handle pod restarts
 			RestartPolicy: corev1.RestartPolicyNever,
 			// use the pod's (stable) name as the hostname
@@ -408,6 +485,9 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 		}
 	}
 
+	// apply the pod customizations specified in the aerospikecluster resource
+	applyPodSpec(aerospikeCluster, pod)
+
 	// if the pod is being created during an upgrade operation
 	// get the corresponding upgradestrategy
 	var upgradeStrategy *versioning.UpgradeStrategy
@@ -418,57 +498,50 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 		}
 	}
 
+	var (
+		newPVCs    []*corev1.PersistentVolumeClaim
+		reusedPVCs []*corev1.PersistentVolumeClaim
+	)
 	for index, namespace := range aerospikeCluster.Spec.Namespaces {
-		// if recreatepersistentvolumeclaims is true, create a new PVC
-		// else get an existing one, and if it does not exist, create one
-		var pvc *corev1.PersistentVolumeClaim
-		if upgradeStrategy != nil && upgradeStrategy.RecreatePersistentVolumeClaims {
-			if pvc, err = r.createPersistentVolumeClaim(aerospikeCluster, pod, &namespace); err != nil {
-				return nil, err
-			}
-		} else {
-			if pvc, err = r.getPersistentVolumeClaim(aerospikeCluster, pod); err != nil {
+		for volumeIndex := 0; volumeIndex < getNamespacePersistentVolumeCount(&namespace); volumeIndex++ {
+			pvc, reused, err := r.attachPersistentVolumeClaim(aerospikeCluster, pod, index, &namespace, volumeIndex, upgradeStrategy)
+			if err != nil {
 				return nil, err
 			}
-			if pvc != nil {
-				// mark the PVC as mounted
-				if err = r.signalMounted(pvc); err != nil {
-					return nil, err
-				}
+			if reused {
+				reusedPVCs = append(reusedPVCs, pvc)
 			} else {
-				if pvc, err = r.createPersistentVolumeClaim(aerospikeCluster, pod, &namespace); err != nil {
-					return nil, err
-				}
+				newPVCs = append(newPVCs, pvc)
 			}
 		}
+	}
 
-		switch namespace.Storage.Type {
-		case common.StorageTypeDevice:
-			// use raw block device
-			pod.Spec.Containers[0].VolumeDevices = append(pod.Spec.Containers[0].VolumeDevices, corev1.VolumeDevice{
-				Name:       fmt.Sprintf("%s-%s", namespaceVolumePrefix, namespace.Name),
-				DevicePath: getIndexBasedDevicePath(index),
-			})
-		case common.StorageTypeFile:
-			// use regular storage
-			pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
-				Name:      fmt.Sprintf("%s-%s", namespaceVolumePrefix, namespace.Name),
-				MountPath: fmt.Sprintf("%s%s", defaultFilePath, namespace.Name),
-			})
-		default:
-			// should not happen, as the type is validated as an enum
-			return nil, fmt.Errorf("unsupported storage type %s", namespace.Storage.Type)
+	// if cross-datacenter replication is enabled, provide a persistent volume
+	// in which aerospike can keep the digest log (if the current version uses
+	// one). the digest log is kept across upgrades even if the namespaces'
+	// persistent volume claims are re-created, so that the records which have
+	// not been shipped yet are not lost.
+	if aerospikeCluster.Spec.XDR != nil && usesXDRDigestLog(aerospikeCluster) {
+		digestLogNamespace := getXDRDigestLogNamespace(aerospikeCluster)
+		if _, _, err := r.attachPersistentVolumeClaim(aerospikeCluster, pod, len(aerospikeCluster.Spec.Namespaces), digestLogNamespace, 0, nil); err != nil {
+			return nil, err
 		}
+	}
 
-		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
-			Name: fmt.Sprintf("%s-%s", namespaceVolumePrefix, pvc.Labels[selectors.LabelNamespaceKey]),
-			VolumeSource: corev1.VolumeSource{
-				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
-					ClaimName: pvc.Name,
-				},
-			},
-		})
+	// nodeId will contain the value used as service.node-id for the pod. it
+	// is recorded in the pod's pvcs so that the aerospike node keeps its id
+	// whenever its data is reused.
+	nodeId, err := computeNodeIdForPod(podName, reusedPVCs, newPVCs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute node id for %s: %v", podName, err)
 	}
+	for _, pvc := range append(reusedPVCs, newPVCs...) {
+		if err := r.signalNodeId(pvc, nodeId); err != nil {
+			return nil, err
+		}
+	}
+	pod.Annotations[nodeIdAnnotation] = nodeId
+	pod.Spec.InitContainers[0].Command = append(pod.Spec.InitContainers[0].Command, "--node-id", nodeId)
 
 	// create the pod
 	res, err := r.kubeclientset.CoreV1().Pods(aerospikeCluster.Namespace).Create(pod)
@@ -531,7 +604,7 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 				return false, fmt.Errorf("pod %s in a failure state has been deleted", meta.Key(currentPod))
 
 			}
-			return isPodRunningAndReady(currentPod), nil
+			return IsPodRunningAndReady(currentPod), nil
 		}
 	}, watchCreatePodTimeout)
 	done <- err == nil
@@ -548,6 +621,86 @@ func (r *AerospikeClusterReconciler) createPodWithIndex(aerospikeCluster *aerosp
 	return currentPod, nil
 }
 
+// attachPersistentVolumeClaim adds to pod the persistent volume claim holding
+// the volume with the specified index for the specified namespace, creating it
+// if necessary. It returns the persistent volume claim and whether it existed
+// before (i.e. whether it holds data from a previous pod).
+func (r *AerospikeClusterReconciler) attachPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, index int, namespace *aerospikev1alpha2.AerospikeNamespaceSpec, volumeIndex int, upgradeStrategy *versioning.UpgradeStrategy) (*corev1.PersistentVolumeClaim, bool, error) {
+	// if recreatepersistentvolumeclaims is true, create a new PVC
+	// else get an existing one, and if it does not exist, create one
+	var (
+		pvc    *corev1.PersistentVolumeClaim
+		reused bool
+		err    error
+	)
+	if upgradeStrategy != nil && upgradeStrategy.RecreatePersistentVolumeClaims {
+		if pvc, err = r.createPersistentVolumeClaim(aerospikeCluster, pod, namespace, volumeIndex); err != nil {
+			return nil, false, err
+		}
+	} else {
+		if pvc, err = r.getPersistentVolumeClaim(aerospikeCluster, pod, namespace, volumeIndex); err != nil {
+			return nil, false, err
+		}
+		// an existing pvc that does not match the storage spec anymore
+		// is left behind so that a new one is created
+		if pvc != nil {
+			matches, err := persistentVolumeClaimMatchesSpec(pvc, namespace)
+			if err != nil {
+				return nil, false, err
+			}
+			if !matches {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+					logfields.Pod:                   meta.Key(pod),
+					logfields.PersistentVolumeClaim: pvc.Name,
+				}).Debug("persistentvolumeclaim does not match the storage spec and will be replaced")
+				pvc = nil
+			}
+		}
+		if pvc != nil {
+			reused = true
+			// mark the PVC as mounted
+			if err = r.signalMounted(pvc); err != nil {
+				return nil, false, err
+			}
+		} else {
+			if pvc, err = r.createPersistentVolumeClaim(aerospikeCluster, pod, namespace, volumeIndex); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	volumeName := getNamespaceVolumeName(namespace.Name, volumeIndex)
+
+	switch namespace.Storage.Type {
+	case common.StorageTypeDevice:
+		// use raw block device
+		pod.Spec.Containers[0].VolumeDevices = append(pod.Spec.Containers[0].VolumeDevices, corev1.VolumeDevice{
+			Name:       volumeName,
+			DevicePath: getIndexBasedDevicePath(aerospikeCluster, index, volumeIndex),
+		})
+	case common.StorageTypeFile:
+		// use regular storage
+		pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: getNamespaceMountPath(namespace.Name, volumeIndex),
+		})
+	default:
+		// should not happen, as the type is validated as an enum
+		return nil, false, fmt.Errorf("unsupported storage type %s", namespace.Storage.Type)
+	}
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: pvc.Name,
+			},
+		},
+	})
+	return pvc, reused, nil
+}
+
 func (r *AerospikeClusterReconciler) deletePod(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
 	// mark the pod PVCs as unmounted with an annotation
 	for _, volume := range pod.Spec.Volumes {
@@ -609,8 +762,61 @@ func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *ae
 		// no pod with the specified index exists
 		return nil
 	}
+	// quiesce the node (if supported) so that it hands off its partitions
+	// to the remaining nodes before being deleted
+	quiesced := r.maybeQuiescePod(aerospikeCluster, pod)
+	// wait for the pod to finish participating in migrations
+	if err := r.waitForMigrationsToFinish(aerospikeCluster, pod); err != nil {
+		// the pod is not going to be deleted, so it must not remain quiesced
+		if quiesced {
+			r.undoQuiescePod(aerospikeCluster, pod)
+		}
+		return err
+	}
+	// delete the pod now that migrations are finished
+	if err := r.deletePod(aerospikeCluster, pod); err != nil {
+		if quiesced {
+			r.undoQuiescePod(aerospikeCluster, pod)
+		}
+		return err
+	}
+
+	// get a list of the pods
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+
+	// tip-clear the name of the current pod
+	// and alumni-reset on all pods
+	var wg sync.WaitGroup
+	wg.Add(len(pods))
+	for _, p := range pods {
+		go func(p *corev1.Pod) {
+			defer wg.Done()
+			if err := tipClearHostname(p, fmt.Sprintf("%s.%s.%s", pod.Name, aerospikeCluster.Name, aerospikeCluster.Namespace)); err != nil {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
+					logfields.Pod:              meta.Key(pod),
+				}).Errorf("failed tip-clear ip on pod %q", meta.Key(p))
+			}
+			if err := alumniReset(p); err != nil {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
+					logfields.Pod:              meta.Key(pod),
+				}).Errorf("failed alumni-reset on pod %q", meta.Key(p))
+			}
+		}(p)
+	}
+	wg.Wait()
+	return nil
+}
+
+// waitForMigrationsToFinish waits for the aerospike node running on pod to
+// finish participating in migrations, giving feedback while it waits.
+func (r *AerospikeClusterReconciler) waitForMigrationsToFinish(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
 	// check whether the pod is participating in migrations
-	migrations, err := podHasMigrationsInProgress(pod)
+	migrations, err := PodHasMigrationsInProgress(pod)
 	if err != nil {
 		return err
 	}
@@ -662,39 +868,6 @@ func (r *AerospikeClusterReconciler) safeDeletePodWithIndex(aerospikeCluster *ae
 		}
 		close(done)
 	}
-	// delete the pod now that migrations are finished
-	if err := r.deletePod(aerospikeCluster, pod); err != nil {
-		return err
-	}
-
-	// get a list of the pods
-	pods, err := r.listClusterPods(aerospikeCluster)
-	if err != nil {
-		return err
-	}
-
-	// tip-clear the name of the current pod
-	// and alumni-reset on all pods
-	var wg sync.WaitGroup
-	wg.Add(len(pods))
-	for _, p := range pods {
-		go func(p *corev1.Pod) {
-			defer wg.Done()
-			if err := tipClearHostname(p, fmt.Sprintf("%s.%s.%s", pod.Name, aerospikeCluster.Name, aerospikeCluster.Namespace)); err != nil {
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-					logfields.Pod:              meta.Key(pod),
-				}).Errorf("failed tip-clear ip on pod %q", meta.Key(p))
-			}
-			if err := alumniReset(p); err != nil {
-				log.WithFields(log.Fields{
-					logfields.AerospikeCluster: pod.Labels[selectors.LabelClusterKey],
-					logfields.Pod:              meta.Key(pod),
-				}).Errorf("failed alumni-reset on pod %q", meta.Key(p))
-			}
-		}(p)
-	}
-	wg.Wait()
 	return nil
 }
 
@@ -784,7 +957,7 @@ func computeMemoryRequest(aerospikeCluster *aerospikev1alpha2.AerospikeCluster)
 		} else {
 			log.WithFields(log.Fields{
 				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
-			}).Warn("failed to parse memory size for namespace %s: %v", err)
+			}).Warnf("failed to parse memory size for namespace %s: %v", ns.Name, err)
 			// ns.MemorySize has been validated before, so it is highly unlikely
 			// than an error occurs at this point. however, if it does occur, we
 			// must return something, and so we pick the default memory request.
@@ -829,6 +1002,26 @@ func computeResourceLimits(aerospikeCluster *aerospikev1alpha2.AerospikeCluster)
 	return corev1.ResourceList{}
 }
 
+// computeNodeIdForPod computes the value to be used as the id of the aerospike
+// node running in the pod with the specified name and persistent volume claims.
+// The id recorded in the reused persistent volume claims is preferred, so that
+// an aerospike node whose data is reused keeps its id. Persistent volume claims
+// created before ids were recorded hold data of the node whose id was derived
+// from the pod's name. Otherwise, a new id is derived from the pod's name and
+// the uid of its first new persistent volume claim, so that it does not depend
+// solely on the pod's index.
+func computeNodeIdForPod(podName string, reusedPVCs, newPVCs []*corev1.PersistentVolumeClaim) (string, error) {
+	for _, pvc := range reusedPVCs {
+		if nodeId := pvc.Annotations[nodeIdAnnotation]; nodeId != "" {
+			return nodeId, nil
+		}
+	}
+	if len(reusedPVCs) > 0 || len(newPVCs) == 0 {
+		return computeNodeId(podName)
+	}
+	return computeNodeId(fmt.Sprintf("%s/%s", podName, newPVCs[0].UID))
+}
+
 // computeNodeId computes the value to be used as the id of the aerospike node
 // that corresponds to podName.
 func computeNodeId(podName string) (string, error) {
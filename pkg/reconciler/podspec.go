@@ -0,0 +1,128 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"encoding/json"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	asstrings "github.com/travelaudience/aerospike-operator/pkg/utils/strings"
+)
+
+// computePodSpecHash returns the hash of the pod customizations and of the
+// asprom sidecar configuration specified for aerospikeCluster. An empty string
+// is returned if none are specified so that existing pods are not restarted
+// needlessly.
+func computePodSpecHash(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (string, error) {
+	var exporter *aerospikev1alpha2.ExporterSpec
+	if aerospikeCluster.Spec.Monitoring != nil {
+		exporter = aerospikeCluster.Spec.Monitoring.Exporter
+	}
+	if aerospikeCluster.Spec.PodSpec == nil && exporter == nil {
+		return "", nil
+	}
+	b, err := json.Marshal(aerospikeCluster.Spec.PodSpec)
+	if err != nil {
+		return "", err
+	}
+	// the asprom sidecar configuration is only hashed when specified, so that
+	// the hash of existing pods does not change
+	if exporter != nil {
+		e, err := json.Marshal(exporter)
+		if err != nil {
+			return "", err
+		}
+		b = append(b, e...)
+	}
+	return asstrings.Hash(string(b)), nil
+}
+
+// applyPodSpec merges the pod customizations specified for aerospikeCluster
+// into pod. Labels, annotations, containers and volumes managed by
+// aerospike-operator always take precedence.
+func applyPodSpec(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) {
+	podSpec := aerospikeCluster.Spec.PodSpec
+	if podSpec == nil {
+		return
+	}
+
+	for key, value := range podSpec.Labels {
+		if _, ok := pod.Labels[key]; !ok {
+			pod.Labels[key] = value
+		}
+	}
+	for key, value := range podSpec.Annotations {
+		if _, ok := pod.Annotations[key]; !ok {
+			pod.Annotations[key] = value
+		}
+	}
+
+	pod.Spec.NodeSelector = podSpec.NodeSelector
+	pod.Spec.Tolerations = podSpec.Tolerations
+	pod.Spec.PriorityClassName = podSpec.PriorityClassName
+	pod.Spec.SecurityContext = podSpec.SecurityContext
+
+	if podSpec.Affinity != nil {
+		if pod.Spec.Affinity == nil {
+			pod.Spec.Affinity = &corev1.Affinity{}
+		}
+		pod.Spec.Affinity.NodeAffinity = podSpec.Affinity.NodeAffinity
+		pod.Spec.Affinity.PodAffinity = podSpec.Affinity.PodAffinity
+		// keep the anti-affinity rule that spreads the pods of the cluster
+		// across nodes, and add any user-provided rules to it
+		if podSpec.Affinity.PodAntiAffinity != nil {
+			if pod.Spec.Affinity.PodAntiAffinity == nil {
+				pod.Spec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+			}
+			pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+				pod.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+				podSpec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution...)
+			pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+				pod.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+				podSpec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution...)
+		}
+	}
+
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, podSpec.Env...)
+	pod.Spec.Containers = append(pod.Spec.Containers, podSpec.Sidecars...)
+	pod.Spec.Volumes = append(pod.Spec.Volumes, podSpec.Volumes...)
+}
+
+// IsReservedContainerName returns whether name is the name of a container
+// managed by aerospike-operator.
+func IsReservedContainerName(name string) bool {
+	switch name {
+	case initContainerName, serverContainerName, aspromContainerName:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsReservedVolumeName returns whether name is (or may be) the name of a volume
+// managed by aerospike-operator.
+func IsReservedVolumeName(name string) bool {
+	switch name {
+	case initialConfigVolumeName, finalConfigVolumeName:
+		return true
+	default:
+		return strings.HasPrefix(name, namespaceVolumePrefix+"-")
+	}
+}
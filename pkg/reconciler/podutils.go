@@ -60,7 +60,8 @@ func podIndex(pod *v1.Pod) int {
 	return res
 }
 
-func isPodRunningAndReady(pod *v1.Pod) bool {
+// IsPodRunningAndReady returns whether the specified pod is running and ready.
+func IsPodRunningAndReady(pod *v1.Pod) bool {
 	return pod.Status.Phase == v1.PodRunning && podutil.IsPodReady(pod)
 }
 
@@ -122,7 +123,9 @@ func (r *AerospikeClusterReconciler) waitForPodCondition(pod *v1.Pod, fn watch.C
 	return nil
 }
 
-func podHasMigrationsInProgress(pod *v1.Pod) (bool, error) {
+// PodHasMigrationsInProgress returns whether the aerospike node running on the
+// specified pod has partition migrations in progress.
+func PodHasMigrationsInProgress(pod *v1.Pod) (bool, error) {
 	client, err := as.NewClient(pod.Status.PodIP, ServicePort)
 	if err != nil {
 		return false, err
@@ -154,14 +157,14 @@ func waitForMigrationsToFinishOnPod(pod *v1.Pod) error {
 	return fmt.Errorf("failed to find node %s in the cluster", pod.Annotations[nodeIdAnnotation])
 }
 
-func runInfoCommandOnPod(pod *v1.Pod, command string) (map[string]string, error) {
+func runInfoCommandOnPod(pod *v1.Pod, commands ...string) (map[string]string, error) {
 	addr := fmt.Sprintf("%s:%d", pod.Status.PodIP, ServicePort)
 	conn, err := as.NewConnection(addr, aerospikeClientTimeout)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
-	return as.RequestInfo(conn, command)
+	return as.RequestInfo(conn, commands...)
 }
 
 func getAerospikeServerVersionFromPod(pod *v1.Pod) (string, error) {
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -60,7 +61,7 @@ func (pvcs fromMostRecent) Less(i, j int) bool {
 	return pvcs[j].CreationTimestamp.Before(&pvcs[i].CreationTimestamp)
 }
 
-func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod) (*v1.PersistentVolumeClaim, error) {
+func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec, volumeIndex int) (*v1.PersistentVolumeClaim, error) {
 	// get all the pvcs owned by the aerospikecluster
 	pvcs, err := r.pvcsLister.PersistentVolumeClaims(aerospikeCluster.Namespace).List(selectors.ResourcesByClusterName(aerospikeCluster.Name))
 	if err != nil {
@@ -70,7 +71,7 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 		return nil, nil
 	}
 
-	// filter the ones associated with the pod
+	// filter the ones associated with the pod and namespace
 	var podPVCs []*v1.PersistentVolumeClaim
 	for _, pvc := range pvcs {
 		// skip pvc if it does not belong to the right pod
@@ -78,6 +79,28 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 		if !ok || podName != pod.Name {
 			continue
 		}
+		// skip pvc if it does not belong to the right namespace and volume
+		if pvc.Labels[selectors.LabelNamespaceKey] != namespace.Name || getPVCVolumeIndex(pvc) != volumeIndex {
+			continue
+		}
+		// skip pvc if it has been replaced, as its data is outdated
+		if _, ok := pvc.Annotations[ReplacedOnAnnotation]; ok {
+			continue
+		}
+		// skip pvc if it is bound to a local persistent volume whose node is
+		// gone, as a pod using it would never be scheduled
+		lost, err := r.isLocalPersistentVolumeLost(pvc)
+		if err != nil {
+			return nil, err
+		}
+		if lost {
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+				logfields.Pod:                   meta.Key(pod),
+				logfields.PersistentVolumeClaim: pvc.Name,
+			}).Debug("skipping persistentvolumeclaim bound to lost local persistentvolume")
+			continue
+		}
 		// retrieve the timestamp of when the pvc was last unmounted.
 		// if not available, skip this pvc.
 		lastUnmountedString, ok := pvc.Annotations[LastUnmountedOnAnnotation]
@@ -118,7 +141,7 @@ func (r *AerospikeClusterReconciler) getPersistentVolumeClaim(aerospikeCluster *
 	return podPVCs[0], nil
 }
 
-func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (*v1.PersistentVolumeClaim, error) {
+func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *v1.Pod, namespace *aerospikev1alpha2.AerospikeNamespaceSpec, volumeIndex int) (*v1.PersistentVolumeClaim, error) {
 	storageSize, err := resource.ParseQuantity(namespace.Storage.Size)
 	if err != nil {
 		return nil, err
@@ -132,9 +155,16 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 		persistentVolumeClaimTTL = *namespace.Storage.PersistentVolumeClaimTTL
 	}
 
+	// the name of the pvc holding the first volume is not suffixed with its
+	// index, for consistency with pvcs created by previous versions
+	generateName := fmt.Sprintf("%s-%s-", pod.Name, namespace.Name)
+	if volumeIndex > 0 {
+		generateName = fmt.Sprintf("%s-%s-%d-", pod.Name, namespace.Name, volumeIndex)
+	}
+
 	claim := &v1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: fmt.Sprintf("%s-%s-", pod.Name, namespace.Name),
+			GenerateName: generateName,
 			Labels: map[string]string{
 				selectors.LabelAppKey:       selectors.LabelAppVal,
 				selectors.LabelNamespaceKey: namespace.Name,
@@ -152,8 +182,9 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 				},
 			},
 			Annotations: map[string]string{
-				PodAnnotation:    pod.Name,
-				PVCTTLAnnotation: persistentVolumeClaimTTL,
+				PodAnnotation:         pod.Name,
+				PVCTTLAnnotation:      persistentVolumeClaimTTL,
+				VolumeIndexAnnotation: strconv.Itoa(volumeIndex),
 			},
 		},
 		Spec: v1.PersistentVolumeClaimSpec{
@@ -169,8 +200,8 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 		},
 	}
 
-	if namespace.Storage.StorageClassName != nil && *namespace.Storage.StorageClassName != "" {
-		claim.Spec.StorageClassName = namespace.Storage.StorageClassName
+	if storageClassName := getVolumeStorageClassName(namespace, volumeIndex); storageClassName != nil {
+		claim.Spec.StorageClassName = storageClassName
 	}
 
 	pvc, err := r.kubeclientset.CoreV1().PersistentVolumeClaims(claim.Namespace).Create(claim)
@@ -190,10 +221,137 @@ func (r *AerospikeClusterReconciler) createPersistentVolumeClaim(aerospikeCluste
 	return pvc, err
 }
 
-// getIndexBasedDevicePath returns the device path for the namespace
-// with the specified index (e.g. 0 --> /dev/xvda, 1 --> /dev/xvdb, ...).
-func getIndexBasedDevicePath(index int) string {
-	return fmt.Sprintf("%s%s", defaultDevicePathPrefix, string('a'+index))
+// persistentVolumeClaimMatchesSpec returns whether pvc can be used to store
+// the data of the specified namespace, i.e. whether it has the requested type
+// and storage class and is at least as large as requested in the namespace's
+// storage spec.
+func persistentVolumeClaimMatchesSpec(pvc *v1.PersistentVolumeClaim, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (bool, error) {
+	if !persistentVolumeClaimMatchesStorageType(pvc, namespace) {
+		return false, nil
+	}
+	storageSize, err := resource.ParseQuantity(namespace.Storage.Size)
+	if err != nil {
+		return false, err
+	}
+	currentSize := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	return currentSize.Cmp(storageSize) >= 0, nil
+}
+
+// persistentVolumeClaimMatchesStorageType returns whether pvc has the volume
+// mode corresponding to the storage type of the specified namespace and, if a
+// storage class is requested for the volume it holds, whether it uses said
+// storage class.
+func persistentVolumeClaimMatchesStorageType(pvc *v1.PersistentVolumeClaim, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) bool {
+	// pvcs created without a volume mode use the filesystem volume mode
+	volumeMode := v1.PersistentVolumeFilesystem
+	if pvc.Spec.VolumeMode != nil {
+		volumeMode = *pvc.Spec.VolumeMode
+	}
+	if volumeMode != volumeModeMap[namespace.Storage.Type] {
+		return false
+	}
+	if storageClassName := getVolumeStorageClassName(namespace, getPVCVolumeIndex(pvc)); storageClassName != nil {
+		return pvc.Spec.StorageClassName != nil && *pvc.Spec.StorageClassName == *storageClassName
+	}
+	return true
+}
+
+// getNamespaceVolumeCount returns the number of persistent volumes across
+// which data in the specified namespace is striped.
+func getNamespaceVolumeCount(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) int {
+	if namespace.Storage.Type == common.StorageTypeMemory {
+		return 0
+	}
+	if namespace.Storage.VolumeCount == nil {
+		return 1
+	}
+	return int(*namespace.Storage.VolumeCount)
+}
+
+// getNamespacePersistentVolumeCount returns the total number of persistent
+// volumes used by the specified namespace, including shadow devices. Shadow
+// devices are numbered after the devices they shadow (e.g. with two devices,
+// volumes 0 and 1 are the devices and volumes 2 and 3 their shadows).
+func getNamespacePersistentVolumeCount(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) int {
+	if hasShadowDevices(namespace) {
+		return 2 * getNamespaceVolumeCount(namespace)
+	}
+	return getNamespaceVolumeCount(namespace)
+}
+
+// hasShadowDevices returns whether the specified namespace uses shadow devices.
+func hasShadowDevices(namespace *aerospikev1alpha2.AerospikeNamespaceSpec) bool {
+	return namespace.Storage.Type == common.StorageTypeDevice && namespace.Storage.Shadow != nil
+}
+
+// isShadowVolume returns whether the persistent volume with the specified index
+// is a shadow device for the specified namespace.
+func isShadowVolume(namespace *aerospikev1alpha2.AerospikeNamespaceSpec, volumeIndex int) bool {
+	return hasShadowDevices(namespace) && volumeIndex >= getNamespaceVolumeCount(namespace)
+}
+
+// getVolumeStorageClassName returns the name of the storage class requested
+// for the persistent volume with the specified index for the specified
+// namespace, or nil if none is requested.
+func getVolumeStorageClassName(namespace *aerospikev1alpha2.AerospikeNamespaceSpec, volumeIndex int) *string {
+	storageClassName := namespace.Storage.StorageClassName
+	if isShadowVolume(namespace, volumeIndex) {
+		storageClassName = namespace.Storage.Shadow.StorageClassName
+	}
+	if storageClassName == nil || *storageClassName == "" {
+		return nil
+	}
+	return storageClassName
+}
+
+// getNamespaceVolumeName returns the name of the pod volume holding the
+// persistent volume with the specified index for the specified namespace. The
+// first volume of each namespace is not suffixed with its index so that
+// existing pods remain unchanged.
+func getNamespaceVolumeName(namespace string, volumeIndex int) string {
+	if volumeIndex == 0 {
+		return fmt.Sprintf("%s-%s", namespaceVolumePrefix, namespace)
+	}
+	return fmt.Sprintf("%s-%s-%d", namespaceVolumePrefix, namespace, volumeIndex)
+}
+
+// getNamespaceMountPath returns the path at which the persistent volume with
+// the specified index for the specified namespace is mounted when using file
+// storage.
+func getNamespaceMountPath(namespace string, volumeIndex int) string {
+	if volumeIndex == 0 {
+		return fmt.Sprintf("%s%s", defaultFilePath, namespace)
+	}
+	return fmt.Sprintf("%s%s-%d", defaultFilePath, namespace, volumeIndex)
+}
+
+// getNamespaceFilePath returns the path to the data file stored in the
+// persistent volume with the specified index for the specified namespace.
+func getNamespaceFilePath(namespace string, volumeIndex int) string {
+	return fmt.Sprintf("%s/%s.dat", getNamespaceMountPath(namespace, volumeIndex), namespace)
+}
+
+// getIndexBasedDevicePath returns the device path for the persistent volume
+// with the specified index for the namespace with the specified index. Devices
+// are numbered sequentially across namespaces (e.g. namespace 0 with two
+// volumes --> /dev/xvda and /dev/xvdb, namespace 1 --> /dev/xvdc, ...).
+func getIndexBasedDevicePath(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, nsIndex, volumeIndex int) string {
+	index := volumeIndex
+	for i := 0; i < nsIndex; i++ {
+		index += getNamespacePersistentVolumeCount(&aerospikeCluster.Spec.Namespaces[i])
+	}
+	return fmt.Sprintf("%s%c", defaultDevicePathPrefix, 'a'+index)
+}
+
+// getPVCVolumeIndex returns the index of the persistent volume held by pvc
+// among those of its namespace. PVCs created before multiple volumes per
+// namespace were supported hold the first volume.
+func getPVCVolumeIndex(pvc *v1.PersistentVolumeClaim) int {
+	index, err := strconv.Atoi(pvc.Annotations[VolumeIndexAnnotation])
+	if err != nil {
+		return 0
+	}
+	return index
 }
 
 func (r *AerospikeClusterReconciler) signalMounted(pvc *v1.PersistentVolumeClaim) error {
@@ -202,6 +360,16 @@ func (r *AerospikeClusterReconciler) signalMounted(pvc *v1.PersistentVolumeClaim
 	return r.patchPVC(oldPVC, pvc)
 }
 
+// signalNodeId records in pvc the id of the aerospike node whose data it holds.
+func (r *AerospikeClusterReconciler) signalNodeId(pvc *v1.PersistentVolumeClaim, nodeId string) error {
+	if pvc.Annotations[nodeIdAnnotation] == nodeId {
+		return nil
+	}
+	oldPVC := pvc.DeepCopy()
+	setPVCAnnotation(pvc, nodeIdAnnotation, nodeId)
+	return r.patchPVC(oldPVC, pvc)
+}
+
 func (r *AerospikeClusterReconciler) signalUnmounted(pvc *v1.PersistentVolumeClaim) error {
 	oldPVC := pvc.DeepCopy()
 	setPVCAnnotation(pvc, LastUnmountedOnAnnotation, time.Now().Format(time.RFC3339))
@@ -0,0 +1,217 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+const (
+	// the info commands used to quiesce a node and to trigger the
+	// rebalancing of partitions
+	quiesceCommand     = "quiesce:"
+	quiesceUndoCommand = "quiesce-undo:"
+	reclusterCommand   = "recluster:"
+	// the response to the quiesce and quiesce-undo info commands when they
+	// succeed
+	quiesceOkResponse = "ok"
+	// the names of the namespace statistics used to determine whether a
+	// quiesced node has handed off all its partitions
+	nsEffectiveIsQuiescedStat = "effective_is_quiesced"
+	nsMasterObjectsStat       = "master_objects"
+)
+
+// maybeQuiescePod quiesces the aerospike node running on pod and waits for it
+// to hand off ownership of all partitions, so that it can be shut down without
+// clients experiencing timeouts. Quiescing is only attempted if the node runs a
+// version of aerospike that supports it and if there are other nodes in the
+// cluster to which partitions can be handed off. Failures are logged but not
+// propagated, as the pod can still be safely deleted after migrations finish.
+// It returns whether the node has been asked to quiesce, in which case
+// undoQuiescePod must be called if the pod ends up not being deleted.
+func (r *AerospikeClusterReconciler) maybeQuiescePod(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) bool {
+	// there must be at least one other ready node to hand partitions off to
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			logfields.Pod:              meta.Key(pod),
+		}).Warnf("failed to list pods, not quiescing: %v", err)
+		return false
+	}
+	var others []*corev1.Pod
+	for _, p := range pods {
+		if p.Name != pod.Name && IsPodRunningAndReady(p) {
+			others = append(others, p)
+		}
+	}
+	if len(others) == 0 {
+		return false
+	}
+
+	// check whether the version of aerospike running on the pod supports
+	// quiescing
+	if !podSupportsQuiesce(pod) {
+		return false
+	}
+
+	quiesced, err := r.quiescePod(aerospikeCluster, pod, others)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			logfields.Pod:              meta.Key(pod),
+		}).Warnf("failed to quiesce node, proceeding without quiescing: %v", err)
+		r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonNodeQuiesceFailed,
+			"failed to quiesce pod %s: %v", meta.Key(pod), err)
+		return quiesced
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.Pod:              meta.Key(pod),
+	}).Debug("node quiesced")
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonNodeQuiesced,
+		"pod %s quiesced", meta.Key(pod))
+	return true
+}
+
+// quiescePod quiesces the aerospike node running on pod, triggers a recluster
+// using the remaining pods, and waits until the node owns no partitions. It
+// returns whether the node has accepted the quiesce command, even if a later
+// step fails.
+func (r *AerospikeClusterReconciler) quiescePod(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, others []*corev1.Pod) (bool, error) {
+	res, err := runInfoCommandOnPod(pod, quiesceCommand)
+	if err != nil {
+		return false, err
+	}
+	if res[quiesceCommand] != quiesceOkResponse {
+		return false, fmt.Errorf("unexpected response to %q: %q", quiesceCommand, res[quiesceCommand])
+	}
+	if err := r.recluster(aerospikeCluster, append(others, pod)); err != nil {
+		return true, err
+	}
+
+	// wait for the node to be effectively quiesced and to stop being master
+	// for any partition in every namespace
+	return true, wait.PollImmediate(quiescePollInterval, quiesceTimeout, func() (bool, error) {
+		for _, ns := range aerospikeCluster.Spec.Namespaces {
+			stats, err := getNamespaceStatistics(pod, ns.Name)
+			if err != nil {
+				return false, err
+			}
+			if stats[nsEffectiveIsQuiescedStat] != "true" || stats[nsMasterObjectsStat] != "0" {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// undoQuiescePod reverts the quiescing of the aerospike node running on pod
+// and triggers a recluster so that the node takes ownership of partitions
+// again. It is used when a quiesced pod ends up not being deleted. Failures
+// are logged but not propagated.
+func (r *AerospikeClusterReconciler) undoQuiescePod(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) {
+	res, err := runInfoCommandOnPod(pod, quiesceUndoCommand)
+	if err == nil && res[quiesceUndoCommand] != quiesceOkResponse {
+		err = fmt.Errorf("unexpected response to %q: %q", quiesceUndoCommand, res[quiesceUndoCommand])
+	}
+	if err == nil {
+		var pods []*corev1.Pod
+		if pods, err = r.listClusterPods(aerospikeCluster); err == nil {
+			var ready []*corev1.Pod
+			for _, p := range pods {
+				if IsPodRunningAndReady(p) {
+					ready = append(ready, p)
+				}
+			}
+			err = r.recluster(aerospikeCluster, ready)
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			logfields.Pod:              meta.Key(pod),
+		}).Errorf("failed to undo quiescing of node: %v", err)
+		return
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		logfields.Pod:              meta.Key(pod),
+	}).Debug("node quiescing undone")
+}
+
+// recluster triggers the rebalancing of partitions across the aerospike
+// nodes. The recluster command is only acted upon by the principal node, so it
+// is sent to every one of the specified pods.
+func (r *AerospikeClusterReconciler) recluster(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pods []*corev1.Pod) error {
+	reclustered := false
+	for _, p := range pods {
+		if _, err := runInfoCommandOnPod(p, reclusterCommand); err != nil {
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+				logfields.Pod:              meta.Key(p),
+			}).Warnf("failed to recluster: %v", err)
+			continue
+		}
+		reclustered = true
+	}
+	if !reclustered {
+		return fmt.Errorf("failed to recluster")
+	}
+	return nil
+}
+
+// podSupportsQuiesce returns whether the version of aerospike running on pod
+// supports quiescing.
+func podSupportsQuiesce(pod *corev1.Pod) bool {
+	build, err := getAerospikeServerVersionFromPod(pod)
+	if err != nil {
+		return false
+	}
+	version, err := versioning.NewVersionFromString(build)
+	if err != nil {
+		return false
+	}
+	return version.SupportsQuiesce()
+}
+
+// getNamespaceStatistics returns the statistics reported by the aerospike node
+// running on pod for the specified aerospike namespace.
+func getNamespaceStatistics(pod *corev1.Pod, namespace string) (map[string]string, error) {
+	command := fmt.Sprintf("namespace/%s", namespace)
+	res, err := runInfoCommandOnPod(pod, command)
+	if err != nil {
+		return nil, err
+	}
+	stats, ok := res[command]
+	if !ok {
+		return nil, fmt.Errorf("failed to get statistics for namespace %s from pod %s", namespace, meta.Key(pod))
+	}
+	return asutils.ParseStatistics(stats), nil
+}
@@ -0,0 +1,322 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	kubeerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+// holdRolledBackVersion keeps aerospikeCluster at the version it is running
+// while a failed upgrade is being (or has been) rolled back. The version in the
+// spec of aerospikeCluster is replaced in memory only, so that the spec is kept
+// as written by the user. The hold is lifted as soon as a different version is
+// requested. It returns whether the rolled back upgrade must be retried, which
+// is the case when the Retry action has been requested after the rollback.
+func (r *AerospikeClusterReconciler) holdRolledBackVersion(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
+	rolledBackVersion, ok := aerospikeCluster.Annotations[UpgradeRolledBackVersionAnnotationKey]
+	if !ok {
+		return false, nil
+	}
+	upgradeStatus := aerospikeCluster.Annotations[UpgradeStatusAnnotationKey]
+	rollingBack := upgradeStatus == UpgradeStatusRollbackAnnotationValue || upgradeStatus == UpgradeStatusRollbackFailedAnnotationValue
+	if !rollingBack && rolledBackVersion != aerospikeCluster.Spec.Version {
+		// a different version has been requested, so the cluster may be
+		// upgraded again
+		oldCluster := aerospikeCluster.DeepCopy()
+		removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeRolledBackVersionAnnotationKey)
+		return false, r.patchCluster(oldCluster, aerospikeCluster)
+	}
+	if !rollingBack && mustRetryUpgrade(aerospikeCluster, rolledBackVersion) {
+		return true, nil
+	}
+	if aerospikeCluster.Status.Version != "" {
+		aerospikeCluster.Spec.Version = aerospikeCluster.Status.Version
+	}
+	return false, nil
+}
+
+// mustRetryUpgrade returns whether the Retry action has been requested for
+// the upgrade of aerospikeCluster to the specified version, and the upgrade
+// has not been retried yet.
+func mustRetryUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, version string) bool {
+	if aerospikeCluster.Spec.UpgradeRecovery == nil || aerospikeCluster.Spec.UpgradeRecovery.Action != common.UpgradeRecoveryActionRetry {
+		return false
+	}
+	return aerospikeCluster.Annotations[UpgradeRetriedVersionAnnotationKey] != version
+}
+
+// maybeRecoverFromFailedUpgrade performs the recovery action specified in
+// .spec.upgradeRecovery for an aerospikecluster whose upgrade has failed or
+// has been paused. It returns whether a recovery action has been performed.
+func (r *AerospikeClusterReconciler) maybeRecoverFromFailedUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (bool, error) {
+	if aerospikeCluster.Spec.UpgradeRecovery == nil {
+		return false, nil
+	}
+	switch aerospikeCluster.Spec.UpgradeRecovery.Action {
+	case common.UpgradeRecoveryActionRollback:
+		return true, r.rollbackUpgrade(aerospikeCluster)
+	case common.UpgradeRecoveryActionRetry:
+		if !mustRetryUpgrade(aerospikeCluster, getRequestedVersion(aerospikeCluster)) {
+			return false, nil
+		}
+		return true, r.retryUpgrade(aerospikeCluster)
+	default:
+		return false, nil
+	}
+}
+
+// rollbackUpgrade rolls aerospikeCluster back to the version it was running
+// before the failed upgrade. The requested version is recorded in an
+// annotation so that the cluster is held at the source version (see
+// holdRolledBackVersion). Pods running the target version are restarted with
+// the source version by the regular reconcile loop, and the pre-upgrade backup
+// (if any) is restored once every pod has been rolled back (see
+// maybeFinishUpgradeRollback).
+func (r *AerospikeClusterReconciler) rollbackUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// parse the source version
+	source, err := versioning.NewVersionFromString(aerospikeCluster.Status.Version)
+	if err != nil {
+		return err
+	}
+	// parse the target version
+	target, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	if err != nil {
+		return err
+	}
+	upgrade := versioning.VersionUpgrade{Source: source, Target: target}
+	strategy, err := upgrade.GetStrategy()
+	if err != nil {
+		return err
+	}
+
+	// the data held by pods that have been upgraded with new persistent volume
+	// claims cannot be read by the source version, so these are replaced
+	if strategy.RecreatePersistentVolumeClaims {
+		if err := r.replaceUpgradedPersistentVolumeClaims(aerospikeCluster, target.String()); err != nil {
+			return err
+		}
+	}
+
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	// the pre-upgrade backup can only be restored if it has finished
+	if finished, err := r.isClusterBackupFinished(aerospikeCluster); err == nil && finished {
+		setAerospikeClusterAnnotation(aerospikeCluster, UpgradeRollbackFromAnnotationKey, target.String())
+	}
+	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeRolledBackVersionAnnotationKey, getRequestedVersion(aerospikeCluster))
+	// the upgrade may be retried once more after it has been rolled back
+	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeRetriedVersionAnnotationKey)
+	clearUpgradePaused(aerospikeCluster, events.ReasonUpgradeRollbackStarted)
+	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusRollbackAnnotationValue)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionUpgradeRollbackInProgress,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             events.ReasonUpgradeRollbackStarted,
+		Message:            fmt.Sprintf("rolling back failed upgrade from version %s to %s", source, target),
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeWarning, events.ReasonUpgradeRollbackStarted,
+		"rolling back failed upgrade from version %s to %s", source, target)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Warnf("rolling back failed upgrade from version %s to %s", source, target)
+
+	return nil
+}
+
+// replaceUpgradedPersistentVolumeClaims marks the persistent volume claims
+// mounted by pods running the specified version of aerospike as replaced, so
+// that these pods are re-created with new persistent volume claims.
+func (r *AerospikeClusterReconciler) replaceUpgradedPersistentVolumeClaims(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, version string) error {
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		podVersion, err := getAerospikeServerVersionFromPod(pod)
+		if err != nil {
+			return err
+		}
+		if podVersion != version {
+			continue
+		}
+		mounted, err := r.getMountedPersistentVolumeClaims(aerospikeCluster, pod)
+		if err != nil {
+			return err
+		}
+		for _, m := range mounted {
+			if err := r.markPersistentVolumeClaimReplaced(aerospikeCluster, pod, m.pvc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// maybeFinishUpgradeRollback restores the pre-upgrade backup of an
+// aerospikecluster that has been rolled back to its source version, and
+// signals that the rollback has finished once the restore is complete.
+func (r *AerospikeClusterReconciler) maybeFinishUpgradeRollback(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	if rollbackFrom, ok := aerospikeCluster.Annotations[UpgradeRollbackFromAnnotationKey]; ok {
+		if err := r.restoreCluster(aerospikeCluster, rollbackFrom); err != nil {
+			return err
+		}
+		finished, err := r.isClusterRestoreFinished(aerospikeCluster, rollbackFrom)
+		if err != nil {
+			if err == errors.ClusterRestoreFailed {
+				return r.signalUpgradeRollbackFailed(aerospikeCluster)
+			}
+			return err
+		}
+		if !finished {
+			// restores did not finish yet, we may quit for now
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			}).Debug("waiting for restores to finish before finishing the rollback")
+			return nil
+		}
+	}
+	return r.signalUpgradeRollbackFinished(aerospikeCluster)
+}
+
+func (r *AerospikeClusterReconciler) signalUpgradeRollbackFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionUpgradeRollbackInProgress,
+		Status:             apiextensions.ConditionFalse,
+		Reason:             events.ReasonUpgradeRollbackFinished,
+		Message:            fmt.Sprintf("rolled back to version %s", aerospikeCluster.Spec.Version),
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey)
+	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeRollbackFromAnnotationKey)
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonUpgradeRollbackFinished,
+		"rolled back to version %s", aerospikeCluster.Spec.Version)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Infof("rolled back to version %s", aerospikeCluster.Spec.Version)
+
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) signalUpgradeRollbackFailed(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionUpgradeRollbackInProgress,
+		Status:             apiextensions.ConditionFalse,
+		Reason:             events.ReasonUpgradeRollbackFailed,
+		Message:            "failed to restore the pre-upgrade backup",
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusRollbackFailedAnnotationValue)
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Event(aerospikeCluster, corev1.EventTypeWarning, events.ReasonUpgradeRollbackFailed,
+		"failed to restore the pre-upgrade backup")
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Warn("failed to restore the pre-upgrade backup")
+
+	return nil
+}
+
+// retryUpgrade retries the failed (or rolled back) upgrade of aerospikeCluster.
+// The pre-upgrade backup is reused if it has finished, and is otherwise
+// performed again. The requested version is recorded in an annotation so that
+// the upgrade is retried only once.
+func (r *AerospikeClusterReconciler) retryUpgrade(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	_, err := r.isClusterBackupFinished(aerospikeCluster)
+	switch {
+	case err == errors.ClusterBackupFailed || kubeerrors.IsNotFound(err):
+		// delete the previous backups so that they are performed again
+		if err := r.deleteClusterBackup(aerospikeCluster); err != nil {
+			return err
+		}
+		removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey)
+	case err != nil:
+		return err
+	default:
+		// the upgrade will be started as soon as the backups finish
+		setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusBackupAnnotationValue)
+	}
+	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeRetriedVersionAnnotationKey, getRequestedVersion(aerospikeCluster))
+	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeRolledBackVersionAnnotationKey)
+	clearUpgradePaused(aerospikeCluster, events.ReasonUpgradeRetried)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionUpgradeRetried,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             events.ReasonUpgradeRetried,
+		Message:            fmt.Sprintf("retrying upgrade from version %s to %s", aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version),
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonUpgradeRetried,
+		"retrying upgrade from version %s to %s", aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Infof("retrying upgrade from version %s to %s", aerospikeCluster.Status.Version, aerospikeCluster.Spec.Version)
+
+	return nil
+}
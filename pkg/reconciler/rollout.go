@@ -0,0 +1,167 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/errors"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+// restartPods restarts the pods with the specified indexes according to the
+// cluster's rollout strategy, i.e. in the requested order and maxUnavailable
+// pods at a time, pausing after each group of pods has been restarted. The
+// time at which the last group of pods was restarted is recorded in an
+// annotation, and an errors.RequeueAfter error is returned until the pause has
+// elapsed so that no worker is blocked. If upgrade is not nil, the pods are
+// restarted as part of the specified upgrade.
+func (r *AerospikeClusterReconciler) restartPods(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, configMap *corev1.ConfigMap, indexes []int, upgrade *versioning.VersionUpgrade) error {
+	if len(indexes) == 0 {
+		// the rollout is over, so forget about the last restart
+		return r.setLastRolloutRestart(aerospikeCluster, nil)
+	}
+	maxUnavailable, order, pause, err := getRolloutStrategy(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	// hold the rollout until the pause after the last restart has elapsed
+	if remaining := getRemainingRolloutPause(aerospikeCluster, pause, time.Now()); remaining > 0 {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Debugf("waiting %s before restarting the next pods", remaining.Round(time.Second))
+		return &errors.RequeueAfter{Delay: remaining}
+	}
+	if order == common.RolloutOrderDescending {
+		for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		}
+	}
+
+	for start := 0; start < len(indexes); start += maxUnavailable {
+		end := start + maxUnavailable
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		group := indexes[start:end]
+
+		// restart every pod in the group at the same time
+		pods := make([]*corev1.Pod, len(group))
+		errs := make([]error, len(group))
+		var wg sync.WaitGroup
+		wg.Add(len(group))
+		for i, index := range group {
+			go func(i, index int) {
+				defer wg.Done()
+				pods[i], errs[i] = r.safeRestartPodWithIndex(aerospikeCluster, configMap, index, upgrade)
+			}(i, index)
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if err != nil {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.PodIndex:         group[i],
+				}).Errorf("failed to restart pod: %v", err)
+				return err
+			}
+		}
+
+		// ensure aerospike is reachable and reports the correct clusterSize
+		for _, pod := range pods {
+			if err := r.ensureClusterSize(aerospikeCluster, pod); err != nil {
+				return err
+			}
+		}
+
+		// wait before restarting the next group of pods
+		if pause > 0 && end < len(indexes) {
+			now := time.Now()
+			if err := r.setLastRolloutRestart(aerospikeCluster, &now); err != nil {
+				return err
+			}
+			log.WithFields(log.Fields{
+				logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+			}).Debugf("waiting %s before restarting the next pods", pause)
+			return &errors.RequeueAfter{Delay: pause}
+		}
+	}
+	return nil
+}
+
+// getRemainingRolloutPause returns how long to wait at the specified time
+// before restarting the next group of pods of the specified cluster, given the
+// time at which the last group of pods was restarted.
+func getRemainingRolloutPause(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pause time.Duration, now time.Time) time.Duration {
+	lastRestart, err := time.Parse(time.RFC3339, aerospikeCluster.Annotations[lastRolloutRestartOnAnnotation])
+	if err != nil {
+		return 0
+	}
+	if remaining := pause - now.Sub(lastRestart); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
+// setLastRolloutRestart records the time at which the last group of pods of
+// the specified cluster was restarted, or removes it if lastRestart is nil.
+func (r *AerospikeClusterReconciler) setLastRolloutRestart(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, lastRestart *time.Time) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	if lastRestart == nil {
+		removeAerospikeClusterAnnotation(aerospikeCluster, lastRolloutRestartOnAnnotation)
+	} else {
+		setAerospikeClusterAnnotation(aerospikeCluster, lastRolloutRestartOnAnnotation, lastRestart.Format(time.RFC3339))
+	}
+
+	return r.patchCluster(oldCluster, aerospikeCluster)
+}
+
+// getRolloutStrategy returns the maximum number of pods to restart at the same
+// time, the order in which to restart pods and the period to wait between
+// restarts requested for the specified cluster, or the defaults.
+func getRolloutStrategy(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) (int, string, time.Duration, error) {
+	maxUnavailable, order, pause := 1, common.RolloutOrderAscending, time.Duration(0)
+	strategy := aerospikeCluster.Spec.RolloutStrategy
+	if strategy == nil {
+		return maxUnavailable, order, pause, nil
+	}
+	if strategy.MaxUnavailable != nil && *strategy.MaxUnavailable > 1 {
+		maxUnavailable = int(*strategy.MaxUnavailable)
+	}
+	if strategy.Order != "" {
+		order = strategy.Order
+	}
+	if strategy.PauseBetweenPods != nil {
+		var err error
+		if pause, err = astime.ParseDuration(*strategy.PauseBetweenPods); err != nil {
+			return 0, "", 0, err
+		}
+	}
+	return maxUnavailable, order, pause, nil
+}
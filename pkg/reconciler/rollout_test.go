@@ -0,0 +1,55 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+)
+
+func TestGetRemainingRolloutPause(t *testing.T) {
+	lastRestart := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		lastRestart string
+		pause       time.Duration
+		now         time.Time
+		expected    time.Duration
+	}{
+		{"no previous restart", "", time.Minute, lastRestart, 0},
+		{"invalid previous restart", "yesterday", time.Minute, lastRestart, 0},
+		{"no pause", lastRestart.Format(time.RFC3339), 0, lastRestart, 0},
+		{"pause not elapsed", lastRestart.Format(time.RFC3339), time.Minute, lastRestart.Add(20 * time.Second), 40 * time.Second},
+		{"pause elapsed", lastRestart.Format(time.RFC3339), time.Minute, lastRestart.Add(2 * time.Minute), 0},
+	}
+	for _, test := range tests {
+		aerospikeCluster := &aerospikev1alpha2.AerospikeCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{},
+			},
+		}
+		if test.lastRestart != "" {
+			aerospikeCluster.Annotations[lastRolloutRestartOnAnnotation] = test.lastRestart
+		}
+		assert.Equal(t, test.expected, getRemainingRolloutPause(aerospikeCluster, test.pause, test.now), test.name)
+	}
+}
@@ -0,0 +1,247 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+)
+
+const (
+	// the names of the namespace statistics and configuration properties used
+	// to determine whether the remaining nodes can absorb the data held by a
+	// cluster after a scale-down
+	nsMemoryUsedBytesStat   = "memory_used_bytes"
+	nsMemorySizeStat        = "memory-size"
+	nsStopWritesPctStat     = "stop-writes-pct"
+	nsDeviceUsedBytesStat   = "device_used_bytes"
+	nsDeviceTotalBytesStat  = "device_total_bytes"
+	nsMinAvailPctStat       = "storage-engine.min-avail-pct"
+	nsLegacyMinAvailPctStat = "min-avail-pct"
+
+	// the defaults used by aerospike for stop-writes-pct and min-avail-pct
+	defaultStopWritesPct = 90
+	defaultMinAvailPct   = 5
+)
+
+// namespaceUsage represents the amount of memory and disk used by an aerospike
+// namespace across the cluster, as well as the maximum amount of memory and
+// disk that can be used in a single node before writes are stopped.
+type namespaceUsage struct {
+	memoryUsed    int64
+	memoryPerNode int64
+	diskUsed      int64
+	diskPerNode   int64
+	// the highest amount of memory and disk used by a single node
+	maxNodeMemoryUsed int64
+	maxNodeDiskUsed   int64
+}
+
+// validateScaleDown checks whether the nodes remaining after scaling down to
+// the node count requested in the spec of aerospikeCluster can absorb the data
+// currently held by pods. If they cannot, the ScaleDownRejected condition is
+// set and false is returned. Pods which are not ready cannot report their
+// usage, so each of them is assumed to hold as much data as the fullest ready
+// pod.
+func (r *AerospikeClusterReconciler) validateScaleDown(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pods []*corev1.Pod) (bool, error) {
+	desiredSize := int(aerospikeCluster.Spec.NodeCount)
+	if desiredSize >= len(pods) {
+		return true, r.signalScaleDownAllowed(aerospikeCluster)
+	}
+
+	// only running and ready pods can report statistics
+	var readyPods []*corev1.Pod
+	for _, pod := range pods {
+		if IsPodRunningAndReady(pod) {
+			readyPods = append(readyPods, pod)
+		}
+	}
+	if len(readyPods) == 0 {
+		return false, r.signalScaleDownRejected(aerospikeCluster, fmt.Sprintf("cannot scale down to %d nodes: the usage of the cluster cannot be observed as no pod is ready", desiredSize))
+	}
+
+	for _, ns := range aerospikeCluster.Spec.Namespaces {
+		usage, err := getNamespaceUsage(readyPods, ns.Name)
+		if err != nil {
+			return false, err
+		}
+		usage.addUnobservedNodes(len(pods) - len(readyPods))
+		if message := usage.checkCapacity(desiredSize); message != "" {
+			return false, r.signalScaleDownRejected(aerospikeCluster, fmt.Sprintf("cannot scale down to %d nodes: %s in namespace %s", desiredSize, message, ns.Name))
+		}
+	}
+	return true, r.signalScaleDownAllowed(aerospikeCluster)
+}
+
+// getNamespaceUsage gathers the statistics reported by each of the specified
+// pods for the specified aerospike namespace and builds a namespaceUsage.
+func getNamespaceUsage(pods []*corev1.Pod, namespace string) (*namespaceUsage, error) {
+	res := &namespaceUsage{}
+	for i, pod := range pods {
+		stats, err := getNamespaceStatistics(pod, namespace)
+		if err != nil {
+			return nil, err
+		}
+		memoryUsed, err := parseInt64Stat(stats, nsMemoryUsedBytesStat)
+		if err != nil {
+			return nil, err
+		}
+		memorySize, err := parseInt64Stat(stats, nsMemorySizeStat)
+		if err != nil {
+			return nil, err
+		}
+		stopWritesPct := parseInt64StatOrDefault(stats, defaultStopWritesPct, nsStopWritesPctStat)
+		diskUsed := parseInt64StatOrDefault(stats, 0, nsDeviceUsedBytesStat)
+		diskTotal := parseInt64StatOrDefault(stats, 0, nsDeviceTotalBytesStat)
+		minAvailPct := parseInt64StatOrDefault(stats, defaultMinAvailPct, nsMinAvailPctStat, nsLegacyMinAvailPctStat)
+
+		// the amount of data (including replicas) held by the cluster is the
+		// sum of the amounts held by each node
+		res.memoryUsed += memoryUsed
+		res.diskUsed += diskUsed
+		if memoryUsed > res.maxNodeMemoryUsed {
+			res.maxNodeMemoryUsed = memoryUsed
+		}
+		if diskUsed > res.maxNodeDiskUsed {
+			res.maxNodeDiskUsed = diskUsed
+		}
+		// the capacity of each node is assumed to be that of the smallest one
+		memoryPerNode := memorySize * stopWritesPct / 100
+		diskPerNode := diskTotal * (100 - minAvailPct) / 100
+		if i == 0 || memoryPerNode < res.memoryPerNode {
+			res.memoryPerNode = memoryPerNode
+		}
+		if i == 0 || diskPerNode < res.diskPerNode {
+			res.diskPerNode = diskPerNode
+		}
+	}
+	return res, nil
+}
+
+// addUnobservedNodes accounts for the data held by the specified number of
+// nodes which could not report their usage, assuming that each of them holds
+// as much data as the fullest node which did.
+func (u *namespaceUsage) addUnobservedNodes(count int) {
+	u.memoryUsed += u.maxNodeMemoryUsed * int64(count)
+	u.diskUsed += u.maxNodeDiskUsed * int64(count)
+}
+
+// checkCapacity returns a message describing why the data held by the cluster
+// does not fit in the specified number of nodes, or an empty string if it does.
+func (u *namespaceUsage) checkCapacity(nodeCount int) string {
+	n := int64(nodeCount)
+	if n <= 0 {
+		return "the cluster must have at least one node"
+	}
+	if u.memoryUsed > u.memoryPerNode*n {
+		return fmt.Sprintf("%d bytes of memory are in use but only %d bytes would be available", u.memoryUsed, u.memoryPerNode*n)
+	}
+	if u.diskUsed > u.diskPerNode*n {
+		return fmt.Sprintf("%d bytes of disk are in use but only %d bytes would be available", u.diskUsed, u.diskPerNode*n)
+	}
+	return ""
+}
+
+// parseInt64Stat parses the value of the statistic with the specified name.
+func parseInt64Stat(stats map[string]string, name string) (int64, error) {
+	v, ok := stats[name]
+	if !ok {
+		return 0, fmt.Errorf("statistic %s not found", name)
+	}
+	return strconv.ParseInt(v, 10, 64)
+}
+
+// parseInt64StatOrDefault parses the value of the first statistic found having
+// one of the specified names, returning def if none can be parsed.
+func parseInt64StatOrDefault(stats map[string]string, def int64, names ...string) int64 {
+	for _, name := range names {
+		if v, err := parseInt64Stat(stats, name); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+func (r *AerospikeClusterReconciler) signalScaleDownRejected(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, message string) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionScaleDownRejected,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             events.ReasonScaleDownRejected,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Event(aerospikeCluster, corev1.EventTypeWarning, events.ReasonScaleDownRejected, message)
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Warn(message)
+
+	return nil
+}
+
+func (r *AerospikeClusterReconciler) signalScaleDownAllowed(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// there is nothing to do unless a scale-down has previously been rejected
+	if c := getCondition(aerospikeCluster, common.ConditionScaleDownRejected); c == nil || c.Status != apiextensions.ConditionTrue {
+		return nil
+	}
+
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionScaleDownRejected,
+		Status:             apiextensions.ConditionFalse,
+		Reason:             events.ReasonScaleDownAllowed,
+		Message:            "scale-down is no longer rejected",
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Event(aerospikeCluster, corev1.EventTypeNormal, events.ReasonScaleDownAllowed,
+		"scale-down is no longer rejected")
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Debug("scale-down is no longer rejected")
+
+	return nil
+}
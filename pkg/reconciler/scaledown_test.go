@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceUsageCheckCapacity(t *testing.T) {
+	tests := []struct {
+		name       string
+		usage      namespaceUsage
+		unobserved int
+		nodeCount  int
+		fits       bool
+	}{
+		{
+			name:      "fits",
+			usage:     namespaceUsage{memoryUsed: 1800, memoryPerNode: 900, diskUsed: 3000, diskPerNode: 1900},
+			nodeCount: 2,
+			fits:      true,
+		},
+		{
+			name:      "memory exceeded",
+			usage:     namespaceUsage{memoryUsed: 1801, memoryPerNode: 900, diskUsed: 3000, diskPerNode: 1900},
+			nodeCount: 2,
+		},
+		{
+			name:      "disk exceeded",
+			usage:     namespaceUsage{memoryUsed: 1800, memoryPerNode: 900, diskUsed: 3801, diskPerNode: 1900},
+			nodeCount: 2,
+		},
+		{
+			name:      "no nodes",
+			usage:     namespaceUsage{},
+			nodeCount: 0,
+		},
+		{
+			name:       "unobserved node fits",
+			usage:      namespaceUsage{memoryUsed: 600, memoryPerNode: 900, maxNodeMemoryUsed: 400},
+			unobserved: 1,
+			nodeCount:  2,
+			fits:       true,
+		},
+		{
+			name:       "unobserved nodes exceed",
+			usage:      namespaceUsage{memoryUsed: 700, memoryPerNode: 900, maxNodeMemoryUsed: 400},
+			unobserved: 3,
+			nodeCount:  2,
+		},
+	}
+	for _, test := range tests {
+		test.usage.addUnobservedNodes(test.unobserved)
+		message := test.usage.checkCapacity(test.nodeCount)
+		if test.fits {
+			assert.Empty(t, message, test.name)
+		} else {
+			assert.NotEmpty(t, message, test.name)
+		}
+	}
+}
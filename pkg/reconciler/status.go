@@ -18,13 +18,17 @@ package reconciler
 
 import (
 	"encoding/json"
+	"fmt"
 	"reflect"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
 	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
 	"github.com/travelaudience/aerospike-operator/pkg/logfields"
 	"github.com/travelaudience/aerospike-operator/pkg/meta"
@@ -37,8 +41,13 @@ func (r *AerospikeClusterReconciler) updateStatus(aerospikeCluster *aerospikev1a
 	// the reconcile loop
 	aerospikeCluster.Status.BackupSpec = aerospikeCluster.Spec.BackupSpec
 	aerospikeCluster.Status.Namespaces = aerospikeCluster.Spec.Namespaces
-	aerospikeCluster.Status.NodeCount = aerospikeCluster.Spec.NodeCount
+	// the node count is kept while scaling down is rejected, as the pods are
+	// not deleted
+	if !isConditionTrue(aerospikeCluster, common.ConditionScaleDownRejected) {
+		aerospikeCluster.Status.NodeCount = aerospikeCluster.Spec.NodeCount
+	}
 	aerospikeCluster.Status.Version = aerospikeCluster.Spec.Version
+	aerospikeCluster.Status.XDR = aerospikeCluster.Spec.XDR
 }
 
 // patchCluster updates the aerospikecluster resource.
@@ -83,10 +92,68 @@ func (r *AerospikeClusterReconciler) patchCluster(old, new *aerospikev1alpha2.Ae
 	return nil
 }
 
-// appendCondition appends the specified condition to the aerospikeCluster
-// object
-func appendCondition(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, condition apiextensions.CustomResourceDefinitionCondition) {
-	aerospikeCluster.Status.Conditions = append(aerospikeCluster.Status.Conditions, condition)
+// setCondition sets the specified condition in the aerospikeCluster object,
+// replacing any existing condition of the same type so that conditions are
+// updated in place. The last transition time of the existing condition is kept
+// if its status does not change. Duplicate conditions of the same type, which
+// may have been appended by previous versions of aerospike-operator, are
+// removed.
+func setCondition(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, condition aerospikev1alpha2.AerospikeClusterCondition) {
+	conditions := make([]aerospikev1alpha2.AerospikeClusterCondition, 0, len(aerospikeCluster.Status.Conditions)+1)
+	found := false
+	for _, c := range aerospikeCluster.Status.Conditions {
+		if c.Type != condition.Type {
+			conditions = append(conditions, c)
+			continue
+		}
+		if found {
+			continue
+		}
+		found = true
+		// the most recent condition of the specified type is the last one
+		if last := getCondition(aerospikeCluster, condition.Type); last.Status == condition.Status {
+			condition.LastTransitionTime = last.LastTransitionTime
+		}
+		conditions = append(conditions, condition)
+	}
+	if !found {
+		conditions = append(conditions, condition)
+	}
+	aerospikeCluster.Status.Conditions = conditions
+}
+
+// clearConditions sets the status of the existing conditions of the specified
+// types in the aerospikeCluster object to False, indicating that the operation
+// they report on has been superseded by the one reported by the condition of
+// type supersededBy. It is used to clear the outcome of a previous operation
+// when a new one starts.
+func clearConditions(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, supersededBy apiextensions.CustomResourceDefinitionConditionType, conditionTypes ...apiextensions.CustomResourceDefinitionConditionType) {
+	for _, conditionType := range conditionTypes {
+		c := getCondition(aerospikeCluster, conditionType)
+		if c == nil || c.Status == apiextensions.ConditionFalse {
+			continue
+		}
+		setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+			Type:               conditionType,
+			Status:             apiextensions.ConditionFalse,
+			ObservedGeneration: c.ObservedGeneration,
+			LastTransitionTime: metav1.NewTime(time.Now()),
+			Reason:             common.ReasonSuperseded,
+			Message:            fmt.Sprintf("superseded by %s", supersededBy),
+		})
+	}
+}
+
+// getCondition returns the condition of the specified type in the
+// aerospikeCluster object, or nil if no such condition exists. If there are
+// several conditions of the specified type, the last one is returned.
+func getCondition(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, conditionType apiextensions.CustomResourceDefinitionConditionType) *aerospikev1alpha2.AerospikeClusterCondition {
+	for i := len(aerospikeCluster.Status.Conditions) - 1; i >= 0; i-- {
+		if aerospikeCluster.Status.Conditions[i].Type == conditionType {
+			return &aerospikeCluster.Status.Conditions[i]
+		}
+	}
+	return nil
 }
 
 // setAerospikeClusterAnnotation sets an annotation with the specified key and value in the
@@ -0,0 +1,354 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/utils/events"
+	astime "github.com/travelaudience/aerospike-operator/pkg/utils/time"
+)
+
+// expandPersistentVolumeClaims expands in place the persistent volume claims
+// of the cluster's pods which are smaller than requested in the storage spec of
+// the corresponding namespace, whenever their storage class allows for volume
+// expansion. Persistent volume claims which cannot be expanded in place are
+// replaced when the corresponding pod is restarted (see ensurePods).
+func (r *AerospikeClusterReconciler) expandPersistentVolumeClaims(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		mounted, err := r.getMountedPersistentVolumeClaims(aerospikeCluster, pod)
+		if err != nil {
+			return err
+		}
+		for _, m := range mounted {
+			pvc, namespace := m.pvc, m.namespace
+			// pvcs of the wrong type or storage class must be replaced
+			if !persistentVolumeClaimMatchesStorageType(pvc, namespace) {
+				continue
+			}
+			matches, err := persistentVolumeClaimMatchesSpec(pvc, namespace)
+			if err != nil {
+				return err
+			}
+			if matches {
+				continue
+			}
+			expandable, err := r.canExpandPersistentVolumeClaim(pvc, namespace)
+			if err != nil {
+				return err
+			}
+			if !expandable {
+				continue
+			}
+			if err := r.signalStorageUpdateInProgress(aerospikeCluster, fmt.Sprintf("expanding the persistentvolumeclaims of pod %s", meta.Key(pod))); err != nil {
+				return err
+			}
+			if err := r.expandPersistentVolumeClaim(aerospikeCluster, pvc, namespace); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandPersistentVolumeClaim updates the storage request of pvc to match the
+// size requested in the storage spec of the specified namespace.
+func (r *AerospikeClusterReconciler) expandPersistentVolumeClaim(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pvc *corev1.PersistentVolumeClaim, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) error {
+	storageSize, err := resource.ParseQuantity(namespace.Storage.Size)
+	if err != nil {
+		return err
+	}
+	oldPVC := pvc.DeepCopy()
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = storageSize
+	if err := r.patchPVC(oldPVC, pvc); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+		logfields.PersistentVolumeClaim: pvc.Name,
+	}).Debugf("persistentvolumeclaim expanded to %s", namespace.Storage.Size)
+	r.recorder.Eventf(aerospikeCluster, corev1.EventTypeNormal, events.ReasonPersistentVolumeClaimExpanded,
+		"persistentvolumeclaim %s expanded to %s", meta.Key(pvc), namespace.Storage.Size)
+	return nil
+}
+
+// canExpandPersistentVolumeClaim returns whether pvc can be expanded in place
+// to match the storage spec of the specified namespace. Only file-based storage
+// is expanded in place, as aerospike cannot make use of the extra space in a
+// raw block device without the device being re-initialized.
+func (r *AerospikeClusterReconciler) canExpandPersistentVolumeClaim(pvc *corev1.PersistentVolumeClaim, namespace *aerospikev1alpha2.AerospikeNamespaceSpec) (bool, error) {
+	if namespace.Storage.Type != common.StorageTypeFile {
+		return false, nil
+	}
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		return false, nil
+	}
+	sc, err := r.scsLister.Get(*pvc.Spec.StorageClassName)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion, nil
+}
+
+// podRequiresNewPersistentVolumeClaims returns whether any of the persistent
+// volume claims mounted by pod must be replaced (see
+// persistentVolumeClaimMustBeReplaced), in which case the pod must be restarted
+// with new persistent volume claims.
+func (r *AerospikeClusterReconciler) podRequiresNewPersistentVolumeClaims(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) (bool, error) {
+	mounted, err := r.getMountedPersistentVolumeClaims(aerospikeCluster, pod)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range mounted {
+		replace, err := r.mountedPersistentVolumeClaimMustBeReplaced(aerospikeCluster, pod, m)
+		if err != nil {
+			return false, err
+		}
+		if replace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// markPersistentVolumeClaimsReplaced marks the persistent volume claims mounted
+// by pod which must be replaced (see persistentVolumeClaimMustBeReplaced) as
+// replaced, so that they are not reused and are eventually deleted by the
+// garbage collector.
+func (r *AerospikeClusterReconciler) markPersistentVolumeClaimsReplaced(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) error {
+	mounted, err := r.getMountedPersistentVolumeClaims(aerospikeCluster, pod)
+	if err != nil {
+		return err
+	}
+	for _, m := range mounted {
+		replace, err := r.mountedPersistentVolumeClaimMustBeReplaced(aerospikeCluster, pod, m)
+		if err != nil {
+			return err
+		}
+		if !replace {
+			continue
+		}
+		if err := r.markPersistentVolumeClaimReplaced(aerospikeCluster, pod, m.pvc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mountedPersistentVolumeClaimMustBeReplaced returns whether the mounted
+// persistent volume claim must be replaced (see
+// persistentVolumeClaimMustBeReplaced). Persistent volume claims which can be
+// expanded in place are never replaced, as they are expanded by
+// expandPersistentVolumeClaims instead (and may have been expanded without the
+// cache reflecting it yet).
+func (r *AerospikeClusterReconciler) mountedPersistentVolumeClaimMustBeReplaced(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, m mountedPersistentVolumeClaim) (bool, error) {
+	if persistentVolumeClaimMatchesStorageType(m.pvc, m.namespace) {
+		expandable, err := r.canExpandPersistentVolumeClaim(m.pvc, m.namespace)
+		if err != nil {
+			return false, err
+		}
+		if expandable {
+			return false, nil
+		}
+	}
+	return persistentVolumeClaimMustBeReplaced(aerospikeCluster, pod, m)
+}
+
+// persistentVolumeClaimMustBeReplaced returns whether the mounted persistent
+// volume claim does not match the storage spec of the corresponding namespace
+// and must therefore be replaced by a new, empty one. Persistent volume claims
+// of namespaces with a replication factor lower than 2 are never replaced, as
+// their data has no other copy in the cluster and would be lost. These can
+// only be expanded in place.
+func persistentVolumeClaimMustBeReplaced(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, m mountedPersistentVolumeClaim) (bool, error) {
+	matches, err := persistentVolumeClaimMatchesSpec(m.pvc, m.namespace)
+	if err != nil {
+		return false, err
+	}
+	if matches {
+		return false, nil
+	}
+	if getReplicationFactor(m.namespace) < 2 {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+			logfields.Pod:                   meta.Key(pod),
+			logfields.PersistentVolumeClaim: m.pvc.Name,
+		}).Warnf("persistentvolumeclaim does not match the storage spec of namespace %s but cannot be replaced as its replication factor is less than 2", m.namespace.Name)
+		return false, nil
+	}
+	return true, nil
+}
+
+// markPersistentVolumeClaimReplaced marks pvc as replaced, so that it is not
+// reused and is eventually deleted by the garbage collector.
+func (r *AerospikeClusterReconciler) markPersistentVolumeClaimReplaced(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod, pvc *corev1.PersistentVolumeClaim) error {
+	oldPVC := pvc.DeepCopy()
+	setPVCAnnotation(pvc, ReplacedOnAnnotation, time.Now().Format(time.RFC3339))
+	// make sure the pvc is eventually garbage collected even if it was
+	// meant to be kept forever
+	if ttl, err := astime.ParseDuration(pvc.Annotations[PVCTTLAnnotation]); err != nil || ttl == 0 {
+		setPVCAnnotation(pvc, PVCTTLAnnotation, replacedPersistentVolumeClaimTTL)
+	}
+	if err := r.patchPVC(oldPVC, pvc); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster:      meta.Key(aerospikeCluster),
+		logfields.Pod:                   meta.Key(pod),
+		logfields.PersistentVolumeClaim: pvc.Name,
+	}).Debug("persistentvolumeclaim marked as replaced")
+	return nil
+}
+
+// mountedPersistentVolumeClaim represents a persistent volume claim mounted by
+// a pod along with the aerospike namespace whose data it holds.
+type mountedPersistentVolumeClaim struct {
+	pvc       *corev1.PersistentVolumeClaim
+	namespace *aerospikev1alpha2.AerospikeNamespaceSpec
+}
+
+// getMountedPersistentVolumeClaims returns the persistent volume claims mounted
+// by pod for storing the data of every aerospike namespace in the cluster.
+func (r *AerospikeClusterReconciler) getMountedPersistentVolumeClaims(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, pod *corev1.Pod) ([]mountedPersistentVolumeClaim, error) {
+	var res []mountedPersistentVolumeClaim
+	for i := range aerospikeCluster.Spec.Namespaces {
+		namespace := &aerospikeCluster.Spec.Namespaces[i]
+		for volumeIndex := 0; volumeIndex < getNamespacePersistentVolumeCount(namespace); volumeIndex++ {
+			pvc, err := r.getMountedPersistentVolumeClaim(pod, namespace.Name, volumeIndex)
+			if err != nil {
+				return nil, err
+			}
+			if pvc != nil {
+				res = append(res, mountedPersistentVolumeClaim{pvc: pvc, namespace: namespace})
+			}
+		}
+	}
+	return res, nil
+}
+
+// getMountedPersistentVolumeClaim returns the persistent volume claim mounted
+// by pod for storing the specified volume of the specified aerospike namespace,
+// or nil if there is none. The persistent volume claim is read from the cache
+// and copied, as callers may modify it before patching it.
+func (r *AerospikeClusterReconciler) getMountedPersistentVolumeClaim(pod *corev1.Pod, namespace string, volumeIndex int) (*corev1.PersistentVolumeClaim, error) {
+	volumeName := getNamespaceVolumeName(namespace, volumeIndex)
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Name != volumeName || volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := r.pvcsLister.PersistentVolumeClaims(pod.Namespace).Get(volume.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return pvc.DeepCopy(), nil
+	}
+	return nil, nil
+}
+
+// signalStorageUpdateInProgress sets the StorageUpdateInProgress condition with
+// the specified message in aerospikeCluster, emitting an event if the update has
+// just started.
+func (r *AerospikeClusterReconciler) signalStorageUpdateInProgress(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, message string) error {
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	started := true
+	if c := getCondition(aerospikeCluster, common.ConditionStorageUpdateInProgress); c != nil && c.Status == apiextensions.ConditionTrue {
+		started = false
+	}
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionStorageUpdateInProgress,
+		Status:             apiextensions.ConditionTrue,
+		Reason:             events.ReasonStorageUpdateStarted,
+		Message:            message,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	if started {
+		r.recorder.Event(aerospikeCluster, corev1.EventTypeNormal, events.ReasonStorageUpdateStarted,
+			"storage update started")
+	}
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Info(message)
+
+	return nil
+}
+
+// signalStorageUpdateFinished clears the StorageUpdateInProgress condition in
+// aerospikeCluster if it is set.
+func (r *AerospikeClusterReconciler) signalStorageUpdateFinished(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) error {
+	// there is nothing to do unless a storage update is in progress
+	if c := getCondition(aerospikeCluster, common.ConditionStorageUpdateInProgress); c == nil || c.Status != apiextensions.ConditionTrue {
+		return nil
+	}
+
+	// grab a copy of aerospikeCluster in its current state so we can later
+	// create a patch
+	oldCluster := aerospikeCluster.DeepCopy()
+
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
+		Type:               common.ConditionStorageUpdateInProgress,
+		Status:             apiextensions.ConditionFalse,
+		Reason:             events.ReasonStorageUpdateFinished,
+		Message:            "persistentvolumeclaims match the storage spec",
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	})
+
+	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
+		return err
+	}
+
+	r.recorder.Event(aerospikeCluster, corev1.EventTypeNormal, events.ReasonStorageUpdateFinished,
+		"storage update finished")
+
+	log.WithFields(log.Fields{
+		logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+	}).Info("storage update finished")
+
+	return nil
+}
@@ -93,7 +93,9 @@ func (r *AerospikeClusterReconciler) signalBackupStarted(aerospikeCluster *aeros
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+	// clear the outcome of any previous pre-upgrade backup
+	clearConditions(aerospikeCluster, common.ConditionAutoBackupStarted, common.ConditionAutoBackupFinished, common.ConditionAutoBackupFailed)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
 		Type:               common.ConditionAutoBackupStarted,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterAutoBackupStarted,
@@ -121,7 +123,8 @@ func (r *AerospikeClusterReconciler) signalBackupFinished(aerospikeCluster *aero
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+	clearConditions(aerospikeCluster, common.ConditionAutoBackupFinished, common.ConditionAutoBackupStarted)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
 		Type:               common.ConditionAutoBackupFinished,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterAutoBackupFinished,
@@ -148,7 +151,8 @@ func (r *AerospikeClusterReconciler) signalBackupFailed(aerospikeCluster *aerosp
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+	clearConditions(aerospikeCluster, common.ConditionAutoBackupFailed, common.ConditionAutoBackupStarted)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
 		Type:               common.ConditionAutoBackupFailed,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterAutoBackupFailed,
@@ -175,7 +179,9 @@ func (r *AerospikeClusterReconciler) signalUpgradeStarted(aerospikeCluster *aero
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+	// clear the outcome of any previous upgrade
+	clearConditions(aerospikeCluster, common.ConditionUpgradeStarted, common.ConditionUpgradeFinished, common.ConditionUpgradeFailed)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
 		Type:               common.ConditionUpgradeStarted,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterUpgradeStarted,
@@ -203,7 +209,8 @@ func (r *AerospikeClusterReconciler) signalUpgradeFailed(aerospikeCluster *aeros
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+	clearConditions(aerospikeCluster, common.ConditionUpgradeFailed, common.ConditionUpgradeStarted)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
 		Type:               common.ConditionUpgradeFailed,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterUpgradeFailed,
@@ -211,6 +218,7 @@ func (r *AerospikeClusterReconciler) signalUpgradeFailed(aerospikeCluster *aeros
 		LastTransitionTime: metav1.NewTime(time.Now()),
 	})
 	setAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey, UpgradeStatusFailedAnnotationValue)
+	clearCanarySoakState(aerospikeCluster)
 
 	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
 		return nil, err
@@ -232,7 +240,8 @@ func (r *AerospikeClusterReconciler) signalUpgradeFinished(aerospikeCluster *aer
 	// create a patch
 	oldCluster := aerospikeCluster.DeepCopy()
 
-	appendCondition(aerospikeCluster, apiextensions.CustomResourceDefinitionCondition{
+	clearConditions(aerospikeCluster, common.ConditionUpgradeFinished, common.ConditionUpgradeStarted)
+	setCondition(aerospikeCluster, aerospikev1alpha2.AerospikeClusterCondition{
 		Type:               common.ConditionUpgradeFinished,
 		Status:             apiextensions.ConditionTrue,
 		Reason:             events.ReasonClusterUpgradeFinished,
@@ -240,6 +249,7 @@ func (r *AerospikeClusterReconciler) signalUpgradeFinished(aerospikeCluster *aer
 		LastTransitionTime: metav1.NewTime(time.Now()),
 	})
 	removeAerospikeClusterAnnotation(aerospikeCluster, UpgradeStatusAnnotationKey)
+	clearCanarySoakState(aerospikeCluster)
 
 	if err := r.patchCluster(oldCluster, aerospikeCluster); err != nil {
 		return nil, err
@@ -52,21 +52,27 @@ func (r *AerospikeClusterReconciler) validateReplicationFactor(aerospikeCluster
 
 func (r *AerospikeClusterReconciler) validateStorageClass(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) bool {
 	for _, ns := range aerospikeCluster.Spec.Namespaces {
-		if ns.Storage.StorageClassName != nil && *ns.Storage.StorageClassName != "" {
-			if _, err := r.scsLister.Get(*ns.Storage.StorageClassName); err != nil {
-				if errors.IsNotFound(err) {
-					r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
-						"storage class %q does not exist",
-						ns.Storage.StorageClassName,
-					)
-				} else {
-					r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
-						"failed to get storage class %q: %v",
-						ns.Storage.StorageClassName,
-						err,
-					)
+		storageClassNames := []*string{ns.Storage.StorageClassName}
+		if ns.Storage.Shadow != nil {
+			storageClassNames = append(storageClassNames, ns.Storage.Shadow.StorageClassName)
+		}
+		for _, storageClassName := range storageClassNames {
+			if storageClassName != nil && *storageClassName != "" {
+				if _, err := r.scsLister.Get(*storageClassName); err != nil {
+					if errors.IsNotFound(err) {
+						r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
+							"storage class %q does not exist",
+							*storageClassName,
+						)
+					} else {
+						r.recorder.Eventf(aerospikeCluster, v1.EventTypeWarning, events.ReasonValidationError,
+							"failed to get storage class %q: %v",
+							*storageClassName,
+							err,
+						)
+					}
+					return false
 				}
-				return false
 			}
 		}
 	}
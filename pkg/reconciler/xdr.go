@@ -0,0 +1,332 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/api/core/v1"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	aerospikev1alpha2 "github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/v1alpha2"
+	"github.com/travelaudience/aerospike-operator/pkg/asutils"
+	"github.com/travelaudience/aerospike-operator/pkg/logfields"
+	"github.com/travelaudience/aerospike-operator/pkg/meta"
+	"github.com/travelaudience/aerospike-operator/pkg/versioning"
+)
+
+const (
+	// xdrDatacenterStateUp is the state reported by aerospike for a remote
+	// datacenter to which records are being shipped successfully
+	xdrDatacenterStateUp = "CLUSTER_UP"
+	// the names of the statistics reported by aerospike for a remote
+	// datacenter that are used to build the status of the aerospikecluster
+	xdrDatacenterStateStat   = "dc_state"
+	xdrDatacenterTimelagStat = "dc_timelag"
+	// the names of the statistic and configuration property reported by
+	// aerospike 5.0 and later for a remote datacenter that are used to build
+	// the status of the aerospikecluster
+	xdrDatacenterLagStat     = "lag"
+	xdrDatacenterStateConfig = "state"
+)
+
+// xdrDatacenterStatus holds the state of and the replication lag towards a
+// remote datacenter as reported by an aerospike node.
+type xdrDatacenterStatus struct {
+	// the state of the remote datacenter, if reported
+	state string
+	// the replication lag (in seconds) towards the remote datacenter
+	lag int64
+}
+
+// xdrSeed represents the address of a node in a remote datacenter.
+type xdrSeed struct {
+	host string
+	port int32
+}
+
+// getXDRSeeds returns the list of seed nodes to use for the specified
+// destination. If destination references an aerospikecluster resource, the
+// headless service of said aerospikecluster is used as the single seed.
+func getXDRSeeds(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, destination aerospikev1alpha2.XDRDestinationSpec) []xdrSeed {
+	if destination.Cluster != nil {
+		namespace := destination.Cluster.Namespace
+		if namespace == "" {
+			namespace = aerospikeCluster.Namespace
+		}
+		return []xdrSeed{
+			{
+				host: fmt.Sprintf("%s.%s", destination.Cluster.Name, namespace),
+				port: ServicePort,
+			},
+		}
+	}
+	seeds := make([]xdrSeed, 0, len(destination.SeedAddresses))
+	for _, address := range destination.SeedAddresses {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			// should not happen, as seed addresses are validated by the
+			// admission webhook
+			continue
+		}
+		p, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			continue
+		}
+		seeds = append(seeds, xdrSeed{host: host, port: int32(p)})
+	}
+	return seeds
+}
+
+// getXDRProps returns the properties used to render the xdr stanza of the
+// aerospike configuration file.
+func getXDRProps(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) map[string]interface{} {
+	digestLogSize := getXDRDigestLogSize(aerospikeCluster)
+
+	datacenters := make([]map[string]interface{}, 0, len(aerospikeCluster.Spec.XDR.Destinations))
+	for _, destination := range aerospikeCluster.Spec.XDR.Destinations {
+		var nodeAddresses []string
+		for _, seed := range getXDRSeeds(aerospikeCluster, destination) {
+			nodeAddresses = append(nodeAddresses, fmt.Sprintf("%s %d", seed.host, seed.port))
+		}
+		datacenters = append(datacenters, map[string]interface{}{
+			xdrDatacenterNameKey:          destination.Name,
+			xdrDatacenterNodeAddressesKey: nodeAddresses,
+			xdrDatacenterNamespacesKey:    destination.Namespaces,
+		})
+	}
+
+	return map[string]interface{}{
+		xdrDigestLogPathKey: path.Join(getNamespaceMountPath(xdrDigestLogNamespaceName, 0), xdrDigestLogFileName),
+		xdrDigestLogSizeKey: digestLogSize,
+		xdrDatacentersKey:   datacenters,
+	}
+}
+
+// getXDRDigestLogSize returns the size of the xdr digest log of
+// aerospikeCluster, in the format used by aerospike (e.g. 1G).
+func getXDRDigestLogSize(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) string {
+	if aerospikeCluster.Spec.XDR.DigestLogSize != nil && *aerospikeCluster.Spec.XDR.DigestLogSize != "" {
+		return *aerospikeCluster.Spec.XDR.DigestLogSize
+	}
+	return defaultXDRDigestLogSize
+}
+
+// getXDRDigestLogNamespace returns the spec of the (internal) namespace whose
+// persistent volume holds the xdr digest log of each pod of aerospikeCluster.
+// This allows for the persistent volume claims holding the digest log to be
+// managed (and reused when pods are re-created) just like those holding the
+// data of the aerospike namespaces, so that the records which have not been
+// shipped yet are not lost.
+func getXDRDigestLogNamespace(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) *aerospikev1alpha2.AerospikeNamespaceSpec {
+	// the size of the digest log is validated by the crd, so err should be nil
+	size, err := strconv.Atoi(strings.TrimSuffix(getXDRDigestLogSize(aerospikeCluster), "G"))
+	if err != nil {
+		size, _ = strconv.Atoi(strings.TrimSuffix(defaultXDRDigestLogSize, "G"))
+	}
+	return &aerospikev1alpha2.AerospikeNamespaceSpec{
+		Name: xdrDigestLogNamespaceName,
+		Storage: aerospikev1alpha2.StorageSpec{
+			Type: common.StorageTypeFile,
+			// aerospike interprets G as gibibytes
+			Size: fmt.Sprintf("%dGi", size+xdrDigestLogVolumeOverheadGi),
+		},
+	}
+}
+
+// IsReservedNamespaceName returns whether name is reserved for the internal
+// use of aerospike-operator and cannot be used as the name of an aerospike
+// namespace.
+func IsReservedNamespaceName(name string) bool {
+	return name == xdrDigestLogNamespaceName
+}
+
+// usesXDRDigestLog returns whether the version of aerospike specified in the
+// spec of aerospikeCluster keeps a digest log for cross-datacenter replication.
+func usesXDRDigestLog(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) bool {
+	version, err := versioning.NewVersionFromString(aerospikeCluster.Spec.Version)
+	// the version is validated by the admission webhook, so err should be nil
+	return err != nil || version.SupportsXDRDigestLog()
+}
+
+// getXDRRemoteDatacenters returns the names of the remote datacenters to which
+// the aerospike namespace with the specified name should be shipped.
+func getXDRRemoteDatacenters(aerospikeCluster *aerospikev1alpha2.AerospikeCluster, namespace string) []string {
+	if aerospikeCluster.Spec.XDR == nil {
+		return nil
+	}
+	var res []string
+	for _, destination := range aerospikeCluster.Spec.XDR.Destinations {
+		for _, ns := range destination.Namespaces {
+			if ns == namespace {
+				res = append(res, destination.Name)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// getXDRDestinationPorts returns the sorted list of unique ports to which the
+// aerospike nodes must be able to connect in order to ship records.
+func getXDRDestinationPorts(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) []int32 {
+	if aerospikeCluster.Spec.XDR == nil {
+		return nil
+	}
+	ports := make(map[int32]bool)
+	for _, destination := range aerospikeCluster.Spec.XDR.Destinations {
+		for _, seed := range getXDRSeeds(aerospikeCluster, destination) {
+			ports[seed.port] = true
+		}
+	}
+	res := make([]int32, 0, len(ports))
+	for port := range ports {
+		res = append(res, port)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i] < res[j]
+	})
+	return res
+}
+
+// getXDRDatacenterInfoCommands returns the info commands used to get the
+// state of and the replication lag towards the remote datacenter with the
+// specified name. Aerospike 5.0 and later report these in the statistics and
+// configuration of the xdr context, while earlier versions report both in the
+// statistics of the remote datacenter.
+func getXDRDatacenterInfoCommands(datacenter string, digestLog bool) []string {
+	if digestLog {
+		return []string{fmt.Sprintf("dc/%s", datacenter)}
+	}
+	return []string{
+		fmt.Sprintf("get-stats:context=xdr;dc=%s", datacenter),
+		fmt.Sprintf("get-config:context=xdr;dc=%s", datacenter),
+	}
+}
+
+// parseXDRDatacenterStatus parses the output of the info commands returned by
+// getXDRDatacenterInfoCommands for the remote datacenter with the specified
+// name.
+func parseXDRDatacenterStatus(res map[string]string, datacenter string, digestLog bool) (xdrDatacenterStatus, error) {
+	commands := getXDRDatacenterInfoCommands(datacenter, digestLog)
+	stats, ok := res[commands[0]]
+	if !ok {
+		return xdrDatacenterStatus{}, fmt.Errorf("failed to get statistics for datacenter %s", datacenter)
+	}
+	if digestLog {
+		// the statistics for a remote datacenter are separated by colons
+		// rather than semicolons
+		s := asutils.ParseStatistics(strings.Replace(stats, ":", ";", -1))
+		return xdrDatacenterStatus{
+			state: s[xdrDatacenterStateStat],
+			lag:   parseInt64(s[xdrDatacenterTimelagStat]),
+		}, nil
+	}
+	status := xdrDatacenterStatus{
+		lag: parseInt64(asutils.ParseStatistics(stats)[xdrDatacenterLagStat]),
+	}
+	if config, ok := res[commands[1]]; ok {
+		status.state = asutils.ParseStatistics(config)[xdrDatacenterStateConfig]
+	}
+	return status, nil
+}
+
+// getXDRDatacenterStatus returns the state of and the replication lag towards
+// the remote datacenter with the specified name as reported by the aerospike
+// node running on pod.
+func getXDRDatacenterStatus(pod *v1.Pod, datacenter string) (xdrDatacenterStatus, error) {
+	digestLog := podUsesXDRDigestLog(pod)
+	res, err := runInfoCommandOnPod(pod, getXDRDatacenterInfoCommands(datacenter, digestLog)...)
+	if err != nil {
+		return xdrDatacenterStatus{}, err
+	}
+	status, err := parseXDRDatacenterStatus(res, datacenter, digestLog)
+	if err != nil {
+		return xdrDatacenterStatus{}, fmt.Errorf("%v from pod %s", err, meta.Key(pod))
+	}
+	return status, nil
+}
+
+// podUsesXDRDigestLog returns whether the version of aerospike running on pod
+// keeps a digest log for cross-datacenter replication. If the version cannot
+// be determined, the digest log is assumed to be used.
+func podUsesXDRDigestLog(pod *v1.Pod) bool {
+	build, err := getAerospikeServerVersionFromPod(pod)
+	if err != nil {
+		return true
+	}
+	version, err := versioning.NewVersionFromString(build)
+	if err != nil {
+		return true
+	}
+	return version.SupportsXDRDigestLog()
+}
+
+// updateXDRStatus updates the status of aerospikeCluster with the state of and
+// the replication lag towards each remote datacenter as reported by the
+// aerospike nodes. Failures to gather statistics are logged but not propagated,
+// as they must not prevent reconciliation.
+func (r *AerospikeClusterReconciler) updateXDRStatus(aerospikeCluster *aerospikev1alpha2.AerospikeCluster) {
+	if aerospikeCluster.Spec.XDR == nil {
+		aerospikeCluster.Status.XDRDestinations = nil
+		return
+	}
+
+	pods, err := r.listClusterPods(aerospikeCluster)
+	if err != nil {
+		log.WithFields(log.Fields{
+			logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+		}).Warnf("failed to list pods: %v", err)
+		return
+	}
+
+	res := make([]aerospikev1alpha2.XDRDestinationStatus, 0, len(aerospikeCluster.Spec.XDR.Destinations))
+	for _, destination := range aerospikeCluster.Spec.XDR.Destinations {
+		status := aerospikev1alpha2.XDRDestinationStatus{
+			Name: destination.Name,
+		}
+		for _, pod := range pods {
+			if !IsPodRunningAndReady(pod) {
+				continue
+			}
+			dcStatus, err := getXDRDatacenterStatus(pod, destination.Name)
+			if err != nil {
+				log.WithFields(log.Fields{
+					logfields.AerospikeCluster: meta.Key(aerospikeCluster),
+					logfields.Pod:              meta.Key(pod),
+				}).Warnf("failed to get xdr statistics for datacenter %s: %v", destination.Name, err)
+				continue
+			}
+			// report the highest lag across all nodes
+			if dcStatus.lag > status.Lag {
+				status.Lag = dcStatus.lag
+			}
+			// report the state as being up only if every node says so
+			if dcStatus.state != "" && (status.State == "" || dcStatus.state != xdrDatacenterStateUp) {
+				status.State = dcStatus.state
+			}
+		}
+		res = append(res, status)
+	}
+	aerospikeCluster.Status.XDRDestinations = res
+}
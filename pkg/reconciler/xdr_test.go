@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reconciler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/travelaudience/aerospike-operator/pkg/apis/aerospike/common"
+	"github.com/travelaudience/aerospike-operator/pkg/pointers"
+)
+
+func TestParseXDRDatacenterStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		digestLog bool
+		res       map[string]string
+		expected  xdrDatacenterStatus
+		expectErr bool
+	}{
+		{
+			name:      "digest log",
+			digestLog: true,
+			res: map[string]string{
+				"dc/dc1": "dc_state=CLUSTER_UP:dc_timelag=7:dc_remote_ship_ok=10",
+			},
+			expected: xdrDatacenterStatus{state: "CLUSTER_UP", lag: 7},
+		},
+		{
+			name: "xdr context",
+			res: map[string]string{
+				"get-stats:context=xdr;dc=dc1":  "lag=3;in_queue=0;in_progress=0;success=10",
+				"get-config:context=xdr;dc=dc1": "state=CLUSTER_UP;namespaces=as-namespace-0",
+			},
+			expected: xdrDatacenterStatus{state: "CLUSTER_UP", lag: 3},
+		},
+		{
+			name: "xdr context without state",
+			res: map[string]string{
+				"get-stats:context=xdr;dc=dc1": "lag=3;in_queue=0;in_progress=0;success=10",
+			},
+			expected: xdrDatacenterStatus{lag: 3},
+		},
+		{
+			name: "xdr context without statistics",
+			res: map[string]string{
+				"dc/dc1": "dc_state=CLUSTER_UP:dc_timelag=7",
+			},
+			expectErr: true,
+		},
+	}
+	for _, test := range tests {
+		status, err := parseXDRDatacenterStatus(test.res, "dc1", test.digestLog)
+		if test.expectErr {
+			assert.Error(t, err, test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, status, test.name)
+	}
+}
+
+func TestGetXDRDigestLogNamespace(t *testing.T) {
+	aerospikeCluster := newTestAerospikeCluster("4.5.3.2")
+	namespace := getXDRDigestLogNamespace(aerospikeCluster)
+	assert.Equal(t, xdrDigestLogNamespaceName, namespace.Name)
+	assert.Equal(t, common.StorageTypeFile, namespace.Storage.Type)
+	assert.Equal(t, "2Gi", namespace.Storage.Size)
+
+	aerospikeCluster.Spec.XDR.DigestLogSize = pointers.NewString("10G")
+	assert.Equal(t, "11Gi", getXDRDigestLogNamespace(aerospikeCluster).Storage.Size)
+}
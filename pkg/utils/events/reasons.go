@@ -45,6 +45,14 @@ const (
 	// upgrade operation finishes on a pod.
 	ReasonNodeUpgradeFinished = "NodeUpgradeFinished"
 
+	// ReasonNodeQuiesced is the reason used in corev1.Event objects created when a pod has
+	// been quiesced before being deleted.
+	ReasonNodeQuiesced = "NodeQuiesced"
+
+	// ReasonNodeQuiesceFailed is the reason used in corev1.Event objects created when a pod
+	// could not be quiesced before being deleted.
+	ReasonNodeQuiesceFailed = "NodeQuiesceFailed"
+
 	// ReasonWaitForMigrationsStarted is the reason used in corev1.Event objects created when
 	// migrations have started.
 	ReasonWaitForMigrationsStarted = "WaitForMigrationsStarted"
@@ -92,4 +100,99 @@ const (
 	// ReasonClusterAutoBackupFailed is the reason used in corev1.Event objects indicating that a
 	// cluster backup has failed
 	ReasonClusterAutoBackupFailed = "ClusterAutoBackupFailed"
+
+	// ReasonScaleDownRejected is the reason used in corev1.Event objects indicating that a
+	// scale-down operation has been rejected due to insufficient capacity
+	ReasonScaleDownRejected = "ScaleDownRejected"
+
+	// ReasonScaleDownAllowed is the reason used in corev1.Event objects indicating that a
+	// previously rejected scale-down operation is no longer rejected
+	ReasonScaleDownAllowed = "ScaleDownAllowed"
+
+	// ReasonStorageUpdateStarted is the reason used in corev1.Event objects indicating that the
+	// persistent volume claims of a cluster have started being updated
+	ReasonStorageUpdateStarted = "StorageUpdateStarted"
+
+	// ReasonStorageUpdateFinished is the reason used in corev1.Event objects indicating that the
+	// persistent volume claims of a cluster have finished being updated
+	ReasonStorageUpdateFinished = "StorageUpdateFinished"
+
+	// ReasonPersistentVolumeClaimExpanded is the reason used in corev1.Event objects indicating
+	// that a persistent volume claim has been expanded in place
+	ReasonPersistentVolumeClaimExpanded = "PersistentVolumeClaimExpanded"
+
+	// ReasonPersistentVolumeClaimsReplaced is the reason used in corev1.Event objects indicating
+	// that the persistent volume claims of a pod have been replaced and its data migrated back
+	ReasonPersistentVolumeClaimsReplaced = "PersistentVolumeClaimsReplaced"
+
+	// ReasonLocalPersistentVolumeLost is the reason used in corev1.Event objects indicating that
+	// a pod cannot be scheduled because the node holding one of its local persistent volumes is gone
+	ReasonLocalPersistentVolumeLost = "LocalPersistentVolumeLost"
+
+	// ReasonLocalPersistentVolumeClaimsReleased is the reason used in corev1.Event objects
+	// indicating that the persistent volume claims bound to lost local persistent volumes have
+	// been released so that the pod can be re-created with new ones
+	ReasonLocalPersistentVolumeClaimsReleased = "LocalPersistentVolumeClaimsReleased"
+
+	// ReasonReconciliationPaused is the reason used in corev1.Event objects indicating that
+	// reconciliation of a cluster has been paused
+	ReasonReconciliationPaused = "ReconciliationPaused"
+
+	// ReasonReconciliationResumed is the reason used in corev1.Event objects indicating that
+	// reconciliation of a cluster has been resumed
+	ReasonReconciliationResumed = "ReconciliationResumed"
+
+	// ReasonUpgradeRollbackStarted is the reason used in corev1.Event objects indicating that a
+	// cluster has started being rolled back after a failed upgrade
+	ReasonUpgradeRollbackStarted = "UpgradeRollbackStarted"
+
+	// ReasonUpgradeRollbackFinished is the reason used in corev1.Event objects indicating that a
+	// cluster has been rolled back after a failed upgrade
+	ReasonUpgradeRollbackFinished = "UpgradeRollbackFinished"
+
+	// ReasonUpgradeRollbackFailed is the reason used in corev1.Event objects indicating that a
+	// cluster could not be rolled back after a failed upgrade
+	ReasonUpgradeRollbackFailed = "UpgradeRollbackFailed"
+
+	// ReasonUpgradeRetried is the reason used in corev1.Event objects indicating that a failed
+	// cluster upgrade has been retried
+	ReasonUpgradeRetried = "UpgradeRetried"
+
+	// ReasonCanarySoakStarted is the reason used in corev1.Event objects indicating that the
+	// health of the canary pods of an upgrade is being checked
+	ReasonCanarySoakStarted = "CanarySoakStarted"
+
+	// ReasonCanarySoakFinished is the reason used in corev1.Event objects indicating that the
+	// canary pods of an upgrade have passed every health check
+	ReasonCanarySoakFinished = "CanarySoakFinished"
+
+	// ReasonCanaryIntegrityCheckFailed is the reason used in corev1.Event objects indicating
+	// that an upgrade has been paused because the cluster integrity check has failed
+	ReasonCanaryIntegrityCheckFailed = "CanaryIntegrityCheckFailed"
+
+	// ReasonCanaryMigrationsCheckFailed is the reason used in corev1.Event objects indicating
+	// that an upgrade has been paused because migrations have not finished during the soak period
+	ReasonCanaryMigrationsCheckFailed = "CanaryMigrationsCheckFailed"
+
+	// ReasonCanaryClientErrorsCheckFailed is the reason used in corev1.Event objects indicating
+	// that an upgrade has been paused because the canary pods have reported too many client errors
+	ReasonCanaryClientErrorsCheckFailed = "CanaryClientErrorsCheckFailed"
+
+	// ReasonCanaryVersionCheckFailed is the reason used in corev1.Event objects indicating that
+	// an upgrade has been paused because a canary pod does not report the target version
+	ReasonCanaryVersionCheckFailed = "CanaryVersionCheckFailed"
+
+	// ReasonUpgradePlanned is the reason used in corev1.Event objects indicating that a cluster
+	// upgrade will go through intermediate versions
+	ReasonUpgradePlanned = "UpgradePlanned"
+
+	// ReasonMonitoringResourceUnavailable is the reason used in corev1.Event objects indicating
+	// that a ServiceMonitor or PrometheusRule cannot be created for a cluster because the
+	// Prometheus Operator is not installed
+	ReasonMonitoringResourceUnavailable = "MonitoringResourceUnavailable"
+
+	// ReasonMonitoringResourceConflict is the reason used in corev1.Event objects indicating
+	// that a ServiceMonitor or PrometheusRule cannot be created for a cluster because a resource
+	// with the same name which is not controlled by the cluster already exists
+	ReasonMonitoringResourceConflict = "MonitoringResourceConflict"
 )
@@ -0,0 +1,279 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// CatalogConfigMapKey is the key of the configmap entry holding a catalog.
+	CatalogConfigMapKey = "catalog.yaml"
+
+	// StrategyNameDefault is the name of DefaultStrategy in a catalog.
+	StrategyNameDefault = "Default"
+	// StrategyNameRecreatePersistentVolumeClaims is the name of To42XYStrategy
+	// in a catalog.
+	StrategyNameRecreatePersistentVolumeClaims = "RecreatePersistentVolumeClaims"
+
+	// versionWildcard matches any version (or the remainder of a version).
+	versionWildcard = "*"
+)
+
+var (
+	// strategies maps the names of the strategies that can be used in a
+	// catalog to the corresponding UpgradeStrategy.
+	strategies = map[string]*UpgradeStrategy{
+		StrategyNameDefault:                        DefaultStrategy,
+		StrategyNameRecreatePersistentVolumeClaims: To42XYStrategy,
+	}
+
+	// majorUpgradeMinVersions maps major versions of Aerospike to the oldest
+	// version from which they can be upgraded to when the catalog does not
+	// list the allowed upgrades. Older versions must be upgraded to this
+	// version first.
+	majorUpgradeMinVersions = map[int]Version{
+		5: {4, 9, 0, 0},
+	}
+
+	// catalog holds the catalog currently in use.
+	catalog   = DefaultCatalog()
+	catalogMu sync.RWMutex
+)
+
+// Catalog describes the versions of Aerospike supported by aerospike-operator
+// and the upgrades allowed between them.
+type Catalog struct {
+	// Versions holds the list of supported versions of Aerospike.
+	Versions []string `json:"versions"`
+	// Upgrades holds the list of allowed upgrades. If empty, minor, patch
+	// and revision upgrades between supported versions are allowed, as well
+	// as major upgrades from the versions listed in majorUpgradeMinVersions,
+	// and the strategy is chosen according to the compiled-in rules.
+	Upgrades []UpgradeEdge `json:"upgrades,omitempty"`
+}
+
+// UpgradeEdge describes an allowed upgrade between two versions of Aerospike.
+type UpgradeEdge struct {
+	// From is the source version. It may end with a "*" wildcard (e.g.
+	// "4.1.*") in order to match several versions.
+	From string `json:"from"`
+	// To is the target version. It may end with a "*" wildcard (e.g.
+	// "4.2.*") in order to match several versions.
+	To string `json:"to"`
+	// Strategy is the name of the strategy used to perform the upgrade.
+	// Defaults to "Default".
+	Strategy string `json:"strategy,omitempty"`
+}
+
+// DefaultCatalog returns the catalog compiled into aerospike-operator.
+func DefaultCatalog() *Catalog {
+	versions := make([]string, len(AerospikeServerSupportedVersions))
+	copy(versions, AerospikeServerSupportedVersions)
+	return &Catalog{
+		Versions: versions,
+	}
+}
+
+// GetCatalog returns the catalog currently in use.
+func GetCatalog() *Catalog {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	return catalog
+}
+
+// SetCatalog validates the specified catalog and starts using it.
+func SetCatalog(c *Catalog) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	catalog = c
+	return nil
+}
+
+// ParseCatalog parses a catalog in YAML or JSON format.
+func ParseCatalog(data []byte) (*Catalog, error) {
+	c := &Catalog{}
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return nil, err
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadCatalogFromFile reads and parses the catalog stored in the specified
+// file.
+func LoadCatalogFromFile(path string) (*Catalog, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCatalog(data)
+}
+
+// LoadCatalogFromConfigMap reads and parses the catalog stored under
+// CatalogConfigMapKey in the specified configmap.
+func LoadCatalogFromConfigMap(kubeClient kubernetes.Interface, namespace, name string) (*Catalog, error) {
+	cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, ok := cm.Data[CatalogConfigMapKey]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s/%s does not contain key %q", namespace, name, CatalogConfigMapKey)
+	}
+	return ParseCatalog([]byte(data))
+}
+
+// Validate checks that every version and upgrade in the catalog is well
+// formed.
+func (c *Catalog) Validate() error {
+	if len(c.Versions) == 0 {
+		return fmt.Errorf("the catalog must contain at least one version")
+	}
+	for _, v := range c.Versions {
+		if _, err := NewVersionFromString(v); err != nil {
+			return fmt.Errorf("invalid version %q: %v", v, err)
+		}
+	}
+	for _, u := range c.Upgrades {
+		if err := validateVersionPattern(u.From); err != nil {
+			return err
+		}
+		if err := validateVersionPattern(u.To); err != nil {
+			return err
+		}
+		if _, ok := strategies[u.strategyName()]; !ok {
+			return fmt.Errorf("unknown upgrade strategy %q", u.Strategy)
+		}
+	}
+	return nil
+}
+
+// isSupported returns whether the specified version is listed in the
+// catalog.
+func (c *Catalog) isSupported(v Version) bool {
+	for _, s := range c.Versions {
+		// versions are compared in their normalized form so that e.g.
+		// "4.2.0" and "4.2.0.0" are considered equal
+		if sv, err := NewVersionFromString(s); err == nil && sv == v {
+			return true
+		}
+	}
+	return false
+}
+
+// getUpgrade returns the first upgrade in the catalog matching the specified
+// version upgrade, or nil if there is none.
+func (c *Catalog) getUpgrade(vu VersionUpgrade) *UpgradeEdge {
+	for i, u := range c.Upgrades {
+		if matchesVersionPattern(u.From, vu.Source) && matchesVersionPattern(u.To, vu.Target) {
+			return &c.Upgrades[i]
+		}
+	}
+	return nil
+}
+
+// isValid returns whether the specified version upgrade is allowed by the
+// catalog.
+func (c *Catalog) isValid(vu VersionUpgrade) bool {
+	if !c.isSupported(vu.Source) || !c.isSupported(vu.Target) {
+		return false
+	}
+	if len(c.Upgrades) == 0 {
+		return vu.isMajorUpgrade() && isAllowedMajorUpgrade(vu) ||
+			vu.isMinorUpgrade() || vu.isPatchUpgrade() || vu.isRevisionUpgrade()
+	}
+	return vu.isUpgrade() && c.getUpgrade(vu) != nil
+}
+
+// getStrategy returns the strategy to use for the specified version upgrade,
+// which must be valid.
+func (c *Catalog) getStrategy(vu VersionUpgrade) *UpgradeStrategy {
+	if len(c.Upgrades) == 0 {
+		// when upgrading from a pre-4.2.X.Y version to 4.2.X.Y or newer
+		// existing data must be erased, so we delete and re-create existing
+		// persistent volume claims.
+		// https://www.aerospike.com/docs/operations/upgrade/storage_to_4_2
+		if vu.Target.Major == 4 && vu.Source.Minor <= 1 && vu.Target.Minor >= 2 {
+			return To42XYStrategy
+		}
+		return DefaultStrategy
+	}
+	return strategies[c.getUpgrade(vu).strategyName()]
+}
+
+// isAllowedMajorUpgrade returns whether the specified major version upgrade is
+// allowed by the compiled-in rules.
+func isAllowedMajorUpgrade(vu VersionUpgrade) bool {
+	min, ok := majorUpgradeMinVersions[vu.Target.Major]
+	return ok && vu.Source.Compare(min) >= 0
+}
+
+// strategyName returns the name of the strategy to use for the upgrade.
+func (u UpgradeEdge) strategyName() string {
+	if u.Strategy == "" {
+		return StrategyNameDefault
+	}
+	return u.Strategy
+}
+
+// validateVersionPattern checks that the specified pattern is either a
+// version or a version prefix followed by a wildcard.
+func validateVersionPattern(pattern string) error {
+	if pattern == versionWildcard {
+		return nil
+	}
+	prefix := pattern
+	if strings.HasSuffix(pattern, "."+versionWildcard) {
+		prefix = strings.TrimSuffix(pattern, "."+versionWildcard)
+		for _, part := range strings.Split(prefix, ".") {
+			if part == "" || strings.Trim(part, "0123456789") != "" {
+				return fmt.Errorf("invalid version pattern %q", pattern)
+			}
+		}
+		return nil
+	}
+	if _, err := NewVersionFromString(prefix); err != nil {
+		return fmt.Errorf("invalid version pattern %q: %v", pattern, err)
+	}
+	return nil
+}
+
+// matchesVersionPattern returns whether the specified version matches the
+// specified pattern.
+func matchesVersionPattern(pattern string, v Version) bool {
+	if pattern == versionWildcard {
+		return true
+	}
+	if strings.HasSuffix(pattern, "."+versionWildcard) {
+		return strings.HasPrefix(v.String(), strings.TrimSuffix(pattern, versionWildcard))
+	}
+	pv, err := NewVersionFromString(pattern)
+	return err == nil && pv == v
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCatalog = `
+versions:
+- 4.3.0.10
+- 4.5.0.5
+- 4.5.3.2
+- 4.9.0.3
+upgrades:
+- from: 4.3.*
+  to: 4.5.*
+- from: 4.5.*
+  to: 4.5.*
+- from: 4.5.3.2
+  to: 4.9.0.3
+  strategy: RecreatePersistentVolumeClaims
+`
+
+func TestParseCatalog(t *testing.T) {
+	tests := []struct {
+		catalog     string
+		expectError bool
+	}{
+		{testCatalog, false},
+		{"versions: []", true},
+		{"versions: [4.0]", true},
+		{"versions: [4.0.0.4]\nunknown: true", true},
+		{"versions: [4.0.0.4]\nupgrades: [{from: 4.*, to: 4.x.*}]", true},
+		{"versions: [4.0.0.4]\nupgrades: [{from: '*', to: 4.1.0.1, strategy: Unknown}]", true},
+		{"versions: [4.0.0.4]\nupgrades: [{from: '*', to: 4.1.0.1}]", false},
+	}
+	for _, test := range tests {
+		_, err := ParseCatalog([]byte(test.catalog))
+		if test.expectError {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	c, err := ParseCatalog([]byte(testCatalog))
+	assert.NoError(t, err)
+	assert.NoError(t, SetCatalog(c))
+	defer SetCatalog(DefaultCatalog())
+
+	assert.True(t, Version{4, 5, 0, 5}.IsSupported())
+	assert.False(t, Version{4, 2, 0, 10}.IsSupported())
+
+	tests := []struct {
+		upgrade  VersionUpgrade
+		valid    bool
+		strategy *UpgradeStrategy
+	}{
+		{VersionUpgrade{Version{4, 3, 0, 10}, Version{4, 5, 0, 5}}, true, DefaultStrategy},
+		{VersionUpgrade{Version{4, 5, 0, 5}, Version{4, 5, 3, 2}}, true, DefaultStrategy},
+		{VersionUpgrade{Version{4, 5, 3, 2}, Version{4, 5, 0, 5}}, false, nil},
+		{VersionUpgrade{Version{4, 5, 3, 2}, Version{4, 9, 0, 3}}, true, To42XYStrategy},
+		{VersionUpgrade{Version{4, 5, 0, 5}, Version{4, 9, 0, 3}}, false, nil},
+		{VersionUpgrade{Version{4, 3, 0, 10}, Version{4, 9, 0, 3}}, false, nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.valid, test.upgrade.IsValid(), test.upgrade)
+		strategy, err := test.upgrade.GetStrategy()
+		if test.valid {
+			assert.NoError(t, err)
+		} else {
+			assert.Error(t, err)
+		}
+		assert.Equal(t, test.strategy, strategy)
+	}
+}
+
+func TestDefaultCatalog(t *testing.T) {
+	assert.NoError(t, DefaultCatalog().Validate())
+
+	tests := []struct {
+		upgrade  VersionUpgrade
+		valid    bool
+		strategy *UpgradeStrategy
+	}{
+		{VersionUpgrade{Version{4, 0, 0, 4}, Version{4, 0, 0, 5}}, true, DefaultStrategy},
+		{VersionUpgrade{Version{4, 1, 0, 6}, Version{4, 2, 0, 3}}, true, To42XYStrategy},
+		{VersionUpgrade{Version{4, 2, 0, 10}, Version{4, 3, 0, 2}}, true, DefaultStrategy},
+		{VersionUpgrade{Version{4, 3, 0, 2}, Version{4, 2, 0, 10}}, false, nil},
+		{VersionUpgrade{Version{4, 3, 0, 10}, Version{4, 3, 0, 11}}, false, nil},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.valid, test.upgrade.IsValid(), test.upgrade)
+		strategy, _ := test.upgrade.GetStrategy()
+		assert.Equal(t, test.strategy, strategy)
+	}
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+var (
+	// quiesceMinVersion is the first version of Aerospike supporting the
+	// "quiesce" info command.
+	// https://www.aerospike.com/docs/operations/manage/cluster_mng/quiescing/
+	quiesceMinVersion = Version{4, 3, 1, 3}
+	// transactionQueuesRemovedVersion is the first version of Aerospike which no
+	// longer supports the "transaction-queues" and
+	// "transaction-threads-per-queue" configuration parameters.
+	transactionQueuesRemovedVersion = Version{4, 7, 0, 0}
+	// xdrDigestLogRemovedVersion is the first version of Aerospike in which xdr
+	// no longer uses a digest log and is configured per datacenter.
+	xdrDigestLogRemovedVersion = Version{5, 0, 0, 0}
+)
+
+// SupportsQuiesce indicates whether the version of Aerospike represented by
+// the current struct supports quiescing nodes before they are shut down.
+func (v Version) SupportsQuiesce() bool {
+	return v.Compare(quiesceMinVersion) >= 0
+}
+
+// SupportsTransactionQueues indicates whether the version of Aerospike
+// represented by the current struct supports configuring transaction queues.
+func (v Version) SupportsTransactionQueues() bool {
+	return v.Compare(transactionQueuesRemovedVersion) < 0
+}
+
+// SupportsXDRDigestLog indicates whether the version of Aerospike represented
+// by the current struct uses a digest log for cross-datacenter replication.
+func (v Version) SupportsXDRDigestLog() bool {
+	return v.Compare(xdrDigestLogRemovedVersion) < 0
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupportsQuiesce(t *testing.T) {
+	tests := []struct {
+		version  Version
+		expected bool
+	}{
+		{Version{4, 2, 0, 10}, false},
+		{Version{4, 3, 0, 10}, false},
+		{Version{4, 3, 1, 2}, false},
+		{Version{4, 3, 1, 3}, true},
+		{Version{4, 3, 1, 4}, true},
+		{Version{4, 5, 0, 1}, true},
+		{Version{5, 0, 0, 1}, true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.version.SupportsQuiesce(), test.version.String())
+	}
+}
+
+func TestSupportsTransactionQueues(t *testing.T) {
+	tests := []struct {
+		version  Version
+		expected bool
+	}{
+		{Version{4, 3, 0, 10}, true},
+		{Version{4, 5, 3, 2}, true},
+		{Version{4, 6, 0, 2}, true},
+		{Version{4, 7, 0, 2}, false},
+		{Version{4, 9, 0, 3}, false},
+		{Version{5, 0, 0, 4}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.version.SupportsTransactionQueues(), test.version.String())
+	}
+}
+
+func TestSupportsXDRDigestLog(t *testing.T) {
+	tests := []struct {
+		version  Version
+		expected bool
+	}{
+		{Version{4, 3, 0, 10}, true},
+		{Version{4, 7, 0, 2}, true},
+		{Version{4, 9, 0, 3}, true},
+		{Version{5, 0, 0, 4}, false},
+		{Version{5, 1, 0, 0}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.version.SupportsXDRDigestLog(), test.version.String())
+	}
+}
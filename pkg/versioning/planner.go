@@ -0,0 +1,140 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"fmt"
+	"sort"
+)
+
+// UpgradePlan represents a sequence of version upgrades ("hops") which take
+// an Aerospike cluster from a source version to a target version through a
+// number of intermediate versions.
+type UpgradePlan []VersionUpgrade
+
+// Versions returns the versions an Aerospike cluster goes through when the
+// current plan is carried out, starting with the source version and ending
+// with the target version.
+func (p UpgradePlan) Versions() []string {
+	if len(p) == 0 {
+		return nil
+	}
+	res := []string{p[0].Source.String()}
+	for _, hop := range p {
+		res = append(res, hop.Target.String())
+	}
+	return res
+}
+
+// PlanUpgrade computes the plan for upgrading from the source to the target
+// version according to the current catalog. Every hop in the plan is a valid
+// upgrade. The plan with the fewest hops is chosen, and among those the one
+// requiring the fewest hops that recreate persistent volume claims.
+func PlanUpgrade(source, target Version) (UpgradePlan, error) {
+	return GetCatalog().planUpgrade(source, target)
+}
+
+// planUpgrade computes the plan for upgrading from the source to the target
+// version according to the catalog.
+func (c *Catalog) planUpgrade(source, target Version) (UpgradePlan, error) {
+	if !c.isSupported(source) {
+		return nil, fmt.Errorf("aerospike version %v is not supported", source)
+	}
+	if !c.isSupported(target) {
+		return nil, fmt.Errorf("aerospike version %v is not supported", target)
+	}
+	if source == target {
+		return UpgradePlan{}, nil
+	}
+
+	// upgrades always go from an older to a newer version, so the versions
+	// and the upgrades between them form an acyclic graph. visiting versions
+	// in ascending order guarantees that the best path to a version is known
+	// before the upgrades from it are considered.
+	versions := c.sortedVersions()
+	type step struct {
+		// the index of the previous version in the path
+		prev int
+		// the number of hops in the path
+		hops int
+		// the number of hops in the path that recreate persistent volume
+		// claims
+		recreations int
+	}
+	steps := make(map[int]step)
+	for i, v := range versions {
+		if v == source {
+			steps[i] = step{prev: -1}
+		}
+	}
+	for i, v := range versions {
+		current, ok := steps[i]
+		if !ok {
+			continue
+		}
+		for j := i + 1; j < len(versions); j++ {
+			hop := VersionUpgrade{v, versions[j]}
+			if !c.isValid(hop) {
+				continue
+			}
+			next := step{prev: i, hops: current.hops + 1, recreations: current.recreations}
+			if c.getStrategy(hop).RecreatePersistentVolumeClaims {
+				next.recreations++
+			}
+			if existing, ok := steps[j]; !ok ||
+				next.hops < existing.hops ||
+				next.hops == existing.hops && next.recreations < existing.recreations {
+				steps[j] = next
+			}
+		}
+	}
+
+	// walk the path backwards from the target version
+	for i, v := range versions {
+		if v != target {
+			continue
+		}
+		if _, ok := steps[i]; !ok {
+			break
+		}
+		var res UpgradePlan
+		for j := i; steps[j].prev >= 0; j = steps[j].prev {
+			res = append(UpgradePlan{{versions[steps[j].prev], versions[j]}}, res...)
+		}
+		return res, nil
+	}
+	return nil, fmt.Errorf("cannot upgrade from version %v to %v", source, target)
+}
+
+// sortedVersions returns the distinct versions listed in the catalog in
+// ascending order.
+func (c *Catalog) sortedVersions() []Version {
+	seen := make(map[Version]bool)
+	res := make([]Version, 0, len(c.Versions))
+	for _, s := range c.Versions {
+		v, err := NewVersionFromString(s)
+		if err != nil || seen[v] {
+			continue
+		}
+		seen[v] = true
+		res = append(res, v)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Compare(res[j]) < 0
+	})
+	return res
+}
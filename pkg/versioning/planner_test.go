@@ -0,0 +1,114 @@
+/*
+Copyright 2018 The aerospike-operator Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package versioning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPlannerCatalog = `
+versions:
+- 4.1.0.1
+- 4.2.0.10
+- 4.3.0.10
+- 4.3.1.3
+- 5.0.0.4
+upgrades:
+- from: 4.1.*
+  to: 4.2.*
+  strategy: RecreatePersistentVolumeClaims
+- from: 4.1.*
+  to: 4.3.*
+  strategy: RecreatePersistentVolumeClaims
+- from: 4.2.*
+  to: 4.3.*
+- from: 4.3.*
+  to: 4.3.*
+- from: 4.3.1.3
+  to: 5.*
+`
+
+func TestPlanUpgrade(t *testing.T) {
+	c, err := ParseCatalog([]byte(testPlannerCatalog))
+	assert.NoError(t, err)
+	assert.NoError(t, SetCatalog(c))
+	defer SetCatalog(DefaultCatalog())
+
+	tests := []struct {
+		source      Version
+		target      Version
+		versions    []string
+		expectError bool
+	}{
+		{Version{4, 1, 0, 1}, Version{4, 1, 0, 1}, nil, false},
+		{Version{4, 1, 0, 1}, Version{4, 2, 0, 10}, []string{"4.1.0.1", "4.2.0.10"}, false},
+		{Version{4, 1, 0, 1}, Version{4, 3, 1, 3}, []string{"4.1.0.1", "4.3.1.3"}, false},
+		{Version{4, 1, 0, 1}, Version{5, 0, 0, 4}, []string{"4.1.0.1", "4.3.1.3", "5.0.0.4"}, false},
+		{Version{4, 2, 0, 10}, Version{5, 0, 0, 4}, []string{"4.2.0.10", "4.3.1.3", "5.0.0.4"}, false},
+		{Version{4, 3, 0, 10}, Version{5, 0, 0, 4}, []string{"4.3.0.10", "4.3.1.3", "5.0.0.4"}, false},
+		{Version{5, 0, 0, 4}, Version{4, 3, 1, 3}, nil, true},
+		{Version{4, 3, 1, 3}, Version{4, 2, 0, 10}, nil, true},
+		{Version{4, 0, 0, 4}, Version{4, 3, 1, 3}, nil, true},
+		{Version{4, 1, 0, 1}, Version{5, 1, 0, 0}, nil, true},
+	}
+	for _, test := range tests {
+		plan, err := PlanUpgrade(test.source, test.target)
+		if test.expectError {
+			assert.Error(t, err, "%v -> %v", test.source, test.target)
+			continue
+		}
+		assert.NoError(t, err, "%v -> %v", test.source, test.target)
+		assert.Equal(t, test.versions, plan.Versions(), "%v -> %v", test.source, test.target)
+		for _, hop := range plan {
+			assert.True(t, hop.IsValid(), "%v -> %v", hop.Source, hop.Target)
+		}
+	}
+}
+
+func TestPlanUpgradePrefersFewerRecreations(t *testing.T) {
+	c, err := ParseCatalog([]byte(`
+versions: [4.1.0.1, 4.2.0.10, 4.3.0.10, 4.4.0.4]
+upgrades:
+- {from: 4.1.*, to: 4.2.*, strategy: RecreatePersistentVolumeClaims}
+- {from: 4.1.*, to: 4.3.*}
+- {from: 4.2.*, to: 4.4.*}
+- {from: 4.3.*, to: 4.4.*}
+`))
+	assert.NoError(t, err)
+
+	plan, err := c.planUpgrade(Version{4, 1, 0, 1}, Version{4, 4, 0, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4.1.0.1", "4.3.0.10", "4.4.0.4"}, plan.Versions())
+}
+
+func TestPlanUpgradeDefaultCatalog(t *testing.T) {
+	// with the default catalog upgrades within a major version are performed
+	// in a single hop
+	plan, err := PlanUpgrade(Version{4, 0, 0, 4}, Version{4, 2, 0, 10})
+	assert.NoError(t, err)
+	assert.Len(t, plan, 1)
+	strategy, err := plan[0].GetStrategy()
+	assert.NoError(t, err)
+	assert.Equal(t, To42XYStrategy, strategy)
+
+	// upgrading to 5.x requires upgrading to 4.9 first
+	plan, err = PlanUpgrade(Version{4, 3, 0, 10}, Version{5, 0, 0, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"4.3.0.10", "4.9.0.3", "5.0.0.4"}, plan.Versions())
+}
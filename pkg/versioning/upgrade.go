@@ -28,57 +28,65 @@ type VersionUpgrade struct {
 // isDowngrade returns a boolean value indicating whether the current transition
 // is a downgrade.
 func (vu VersionUpgrade) isDowngrade() bool {
-	return vu.Target.Major < vu.Source.Major ||
-		vu.Target.Minor < vu.Source.Minor ||
-		vu.Target.Patch < vu.Source.Patch ||
-		vu.Target.Revision < vu.Target.Revision
+	return vu.Target.Compare(vu.Source) < 0
+}
+
+// isUpgrade returns a boolean value indicating whether the current transition
+// is an upgrade (i.e. whether the target version is newer than the source
+// version). Every upgrade is exactly one of a major, minor, patch or revision
+// upgrade, according to the most significant number that differs between the
+// source and target versions.
+func (vu VersionUpgrade) isUpgrade() bool {
+	return vu.Target.Compare(vu.Source) > 0
 }
 
 // isMajorUpgrade returns a boolean value indicating whether the current
 // transition is a major version upgrade.
 func (vu VersionUpgrade) isMajorUpgrade() bool {
-	return !vu.isDowngrade() && vu.Target.Major > vu.Source.Major
+	return vu.isUpgrade() && vu.Target.Major != vu.Source.Major
 }
 
 // isMinorUpgrade returns a boolean value indicating whether the current
 // transition is a minor version upgrade.
 func (vu VersionUpgrade) isMinorUpgrade() bool {
-	return !vu.isDowngrade() && !vu.isMajorUpgrade() && vu.Target.Minor > vu.Source.Minor
+	return vu.isUpgrade() && vu.Target.Major == vu.Source.Major &&
+		vu.Target.Minor != vu.Source.Minor
 }
 
 // isPatchUpgrade returns a boolean value indicating whether the current
 // transition is a patch version upgrade.
 func (vu VersionUpgrade) isPatchUpgrade() bool {
-	return !vu.isDowngrade() && !vu.isMajorUpgrade() && !vu.isMinorUpgrade() && vu.Target.Patch > vu.Source.Patch
+	return vu.isUpgrade() && vu.Target.Major == vu.Source.Major &&
+		vu.Target.Minor == vu.Source.Minor &&
+		vu.Target.Patch != vu.Source.Patch
 }
 
-// isPatchUpgrade returns a boolean value indicating whether the current
+// isRevisionUpgrade returns a boolean value indicating whether the current
 // transition is a revision version upgrade.
 func (vu VersionUpgrade) isRevisionUpgrade() bool {
-	return !vu.isDowngrade() && !vu.isMajorUpgrade() && !vu.isMinorUpgrade() && !vu.isPatchUpgrade() && vu.Target.Revision > vu.Source.Revision
+	return vu.isUpgrade() && vu.Target.Major == vu.Source.Major &&
+		vu.Target.Minor == vu.Source.Minor &&
+		vu.Target.Patch == vu.Source.Patch &&
+		vu.Target.Revision != vu.Source.Revision
 }
 
-// IsValid indicates whether the transition is valid. This initially means
-// that the source and target versions are both well-known, supported versions,
-// and that the transition is patch or revision only.
+// IsValid indicates whether the transition is valid. This means that the
+// source and target versions are both supported versions according to the
+// current catalog, and that the catalog allows the transition (by default,
+// minor, patch and revision upgrades are allowed).
 func (vu VersionUpgrade) IsValid() bool {
-	return vu.Source.IsSupported() && vu.Target.IsSupported() && (vu.isMinorUpgrade() || vu.isPatchUpgrade() || vu.isRevisionUpgrade())
+	return GetCatalog().isValid(vu)
 }
 
 // GetStrategy returns the UpgradeStrategy for performing the
 // current upgrade operation
 func (vu VersionUpgrade) GetStrategy() (*UpgradeStrategy, error) {
+	// grab the current catalog so that the upgrade is validated and the
+	// strategy is chosen against the same catalog
+	c := GetCatalog()
 	// return nil if the upgrade is not valid
-	if !vu.IsValid() {
+	if !c.isValid(vu) {
 		return nil, fmt.Errorf("cannot upgrade from version %v to %v", vu.Source, vu.Target)
 	}
-
-	// when upgrading from a pre-4.2.X.Y version to 4.2.X.Y or newer existing
-	// data must be erased, so we delete and re-create existing persistent
-	// volume claims.
-	// https://www.aerospike.com/docs/operations/upgrade/storage_to_4_2
-	if vu.Target.Major == 4 && vu.Source.Minor <= 1 && vu.Target.Minor >= 2 {
-		return To42XYStrategy, nil
-	}
-	return DefaultStrategy, nil
+	return c.getStrategy(vu), nil
 }
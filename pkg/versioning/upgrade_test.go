@@ -17,6 +17,7 @@ limitations under the License.
 package versioning
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -173,3 +174,107 @@ func TestIsRevision(t *testing.T) {
 		assert.Equal(t, test.upgrade.isRevisionUpgrade(), test.result)
 	}
 }
+
+func TestIsDowngrade(t *testing.T) {
+	tests := []struct {
+		upgrade VersionUpgrade
+		result  bool
+	}{
+		{VersionUpgrade{
+			Version{4, 0, 0, 0},
+			Version{4, 0, 0, 0},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 3, 0, 2},
+			Version{5, 0, 0, 1},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 5, 3, 2},
+			Version{4, 6, 0, 1},
+		}, false},
+		{VersionUpgrade{
+			Version{4, 0, 0, 5},
+			Version{4, 0, 0, 4},
+		}, true},
+		{VersionUpgrade{
+			Version{5, 0, 0, 1},
+			Version{4, 3, 0, 2},
+		}, true},
+		{VersionUpgrade{
+			Version{4, 2, 0, 10},
+			Version{4, 2, 0, 3},
+		}, true},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.result, test.upgrade.isDowngrade(), "%v -> %v", test.upgrade.Source, test.upgrade.Target)
+	}
+}
+
+// checkUpgradeClassification checks that the upgrade from v to o is classified
+// as exactly one of a downgrade, a no-op or a major, minor, patch or revision
+// upgrade, according to the most significant number that differs between v
+// and o.
+func checkUpgradeClassification(t *testing.T, v, o Version) {
+	vu := VersionUpgrade{v, o}
+	var expected string
+	switch {
+	case o.Compare(v) < 0:
+		expected = "downgrade"
+	case o == v:
+		expected = "none"
+	case o.Major != v.Major:
+		expected = "major"
+	case o.Minor != v.Minor:
+		expected = "minor"
+	case o.Patch != v.Patch:
+		expected = "patch"
+	default:
+		expected = "revision"
+	}
+	actual := map[string]bool{
+		"downgrade": vu.isDowngrade(),
+		"major":     vu.isMajorUpgrade(),
+		"minor":     vu.isMinorUpgrade(),
+		"patch":     vu.isPatchUpgrade(),
+		"revision":  vu.isRevisionUpgrade(),
+	}
+	for kind, result := range actual {
+		assert.Equal(t, kind == expected, result, "%v -> %v is %s", v, o, kind)
+	}
+	// an upgrade and the opposite transition cannot both be upgrades
+	assert.False(t, vu.isUpgrade() && VersionUpgrade{o, v}.isUpgrade(), "%v <-> %v", v, o)
+	assert.Equal(t, vu.isDowngrade(), VersionUpgrade{o, v}.isUpgrade(), "%v <-> %v", v, o)
+}
+
+func TestUpgradeClassificationExhaustive(t *testing.T) {
+	versions := smallVersions(3)
+	for _, v := range versions {
+		for _, o := range versions {
+			checkUpgradeClassification(t, v, o)
+		}
+	}
+}
+
+func TestUpgradeClassificationRandomized(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		checkUpgradeClassification(t, randomVersion(r, 20), randomVersion(r, 20))
+	}
+}
+
+func TestIsValidDefaultCatalog(t *testing.T) {
+	versions := make([]Version, 0, len(AerospikeServerSupportedVersions))
+	for _, s := range AerospikeServerSupportedVersions {
+		v, err := NewVersionFromString(s)
+		assert.NoError(t, err)
+		versions = append(versions, v)
+	}
+	for _, v := range versions {
+		for _, o := range versions {
+			vu := VersionUpgrade{v, o}
+			// major upgrades are only allowed from 4.9 onwards
+			expected := v.Compare(o) < 0 && (v.Major == o.Major || v.Compare(Version{4, 9, 0, 0}) >= 0)
+			assert.Equal(t, expected, vu.IsValid(), "%v -> %v", v, o)
+		}
+	}
+}
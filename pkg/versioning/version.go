@@ -56,18 +56,31 @@ func (v Version) String() string {
 	return fmt.Sprintf("%d.%d.%d.%d", v.Major, v.Minor, v.Patch, v.Revision)
 }
 
-// IsSupported indicated whether the version of Aerospike represented by the
-// current struct is supported by the operator.
-func (v Version) IsSupported() bool {
-	return contains(AerospikeServerSupportedVersions, v.String())
-}
-
-// contains returns a boolean indicating whether e is contained in the s slice.
-func contains(s []string, e string) bool {
-	for _, a := range s {
-		if a == e {
-			return true
+// Compare compares the version of Aerospike represented by the current struct
+// with o. It returns -1 if the current version is older than o, 0 if both
+// versions are the same, and +1 if the current version is newer than o.
+// Versions are ordered by their major, minor, patch and revision numbers, in
+// this order of precedence.
+func (v Version) Compare(o Version) int {
+	for _, p := range [][2]int{
+		{v.Major, o.Major},
+		{v.Minor, o.Minor},
+		{v.Patch, o.Patch},
+		{v.Revision, o.Revision},
+	} {
+		switch {
+		case p[0] < p[1]:
+			return -1
+		case p[0] > p[1]:
+			return +1
 		}
 	}
-	return false
+	return 0
+}
+
+// IsSupported indicated whether the version of Aerospike represented by the
+// current struct is supported by the operator according to the current
+// catalog.
+func (v Version) IsSupported() bool {
+	return GetCatalog().isSupported(v)
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package versioning
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -55,3 +56,93 @@ func TestString(t *testing.T) {
 		assert.Equal(t, test.version.String(), test.versionString)
 	}
 }
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		v        Version
+		o        Version
+		expected int
+	}{
+		{Version{4, 0, 0, 4}, Version{4, 0, 0, 4}, 0},
+		{Version{4, 0, 0, 4}, Version{4, 0, 0, 5}, -1},
+		{Version{4, 0, 0, 5}, Version{4, 0, 0, 4}, +1},
+		{Version{4, 2, 0, 10}, Version{4, 2, 0, 3}, +1},
+		{Version{4, 3, 0, 2}, Version{5, 0, 0, 1}, -1},
+		{Version{5, 0, 0, 1}, Version{4, 3, 0, 2}, +1},
+		{Version{4, 5, 3, 2}, Version{4, 6, 0, 1}, -1},
+		{Version{4, 5, 3, 2}, Version{4, 5, 0, 5}, +1},
+		{Version{4, 1, 0, 6}, Version{4, 2, 0, 3}, -1},
+		{Version{10, 0, 0, 0}, Version{9, 9, 9, 9}, +1},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.v.Compare(test.o), "%v <=> %v", test.v, test.o)
+	}
+}
+
+// smallVersions returns every version whose numbers are all smaller than n.
+func smallVersions(n int) []Version {
+	res := make([]Version, 0, n*n*n*n)
+	for major := 0; major < n; major++ {
+		for minor := 0; minor < n; minor++ {
+			for patch := 0; patch < n; patch++ {
+				for revision := 0; revision < n; revision++ {
+					res = append(res, Version{major, minor, patch, revision})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// randomVersion returns a random version with numbers smaller than n.
+func randomVersion(r *rand.Rand, n int) Version {
+	return Version{r.Intn(n), r.Intn(n), r.Intn(n), r.Intn(n)}
+}
+
+// checkCompareProperties checks that Compare is a total order on v, o and p
+// which is consistent with the lexicographic order of their numbers.
+func checkCompareProperties(t *testing.T, v, o, p Version) {
+	c := v.Compare(o)
+	// Compare is consistent with the lexicographic order of the numbers
+	expected := 0
+	for i, n := range []int{v.Major, v.Minor, v.Patch, v.Revision} {
+		m := []int{o.Major, o.Minor, o.Patch, o.Revision}[i]
+		if n != m {
+			if n < m {
+				expected = -1
+			} else {
+				expected = +1
+			}
+			break
+		}
+	}
+	assert.Equal(t, expected, c, "%v <=> %v", v, o)
+	// Compare is reflexive and antisymmetric
+	assert.Equal(t, 0, v.Compare(v), "%v <=> %v", v, v)
+	assert.Equal(t, -c, o.Compare(v), "%v <=> %v", o, v)
+	assert.Equal(t, c == 0, v == o, "%v <=> %v", v, o)
+	// Compare is transitive
+	if c <= 0 && o.Compare(p) <= 0 {
+		assert.True(t, v.Compare(p) <= 0, "%v <= %v <= %v", v, o, p)
+	}
+	// Compare is preserved by the string representation
+	parsed, err := NewVersionFromString(v.String())
+	assert.NoError(t, err)
+	assert.Equal(t, c, parsed.Compare(o), "%v <=> %v", parsed, o)
+}
+
+func TestCompareExhaustive(t *testing.T) {
+	versions := smallVersions(3)
+	for _, v := range versions {
+		for _, o := range versions {
+			checkCompareProperties(t, v, o, versions[len(versions)/2])
+		}
+	}
+}
+
+func TestCompareRandomized(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		checkCompareProperties(t, randomVersion(r, 20), randomVersion(r, 20), randomVersion(r, 20))
+	}
+}
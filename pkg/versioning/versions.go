@@ -32,6 +32,13 @@ const (
 	aerospikeServer_4_3_0_7  = "4.3.0.7"
 	aerospikeServer_4_3_0_8  = "4.3.0.8"
 	aerospikeServer_4_3_0_10 = "4.3.0.10"
+	aerospikeServer_4_5_0_5  = "4.5.0.5"
+	aerospikeServer_4_5_3_2  = "4.5.3.2"
+	aerospikeServer_4_6_0_2  = "4.6.0.2"
+	aerospikeServer_4_7_0_2  = "4.7.0.2"
+	aerospikeServer_4_8_0_1  = "4.8.0.1"
+	aerospikeServer_4_9_0_3  = "4.9.0.3"
+	aerospikeServer_5_0_0_4  = "5.0.0.4"
 )
 
 var (
@@ -53,5 +60,12 @@ var (
 		aerospikeServer_4_3_0_7,
 		aerospikeServer_4_3_0_8,
 		aerospikeServer_4_3_0_10,
+		aerospikeServer_4_5_0_5,
+		aerospikeServer_4_5_3_2,
+		aerospikeServer_4_6_0_2,
+		aerospikeServer_4_7_0_2,
+		aerospikeServer_4_8_0_1,
+		aerospikeServer_4_9_0_3,
+		aerospikeServer_5_0_0_4,
 	}
 )